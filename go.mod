@@ -3,9 +3,12 @@ module github.com/softwaresale/lambdagen
 go 1.23.0
 
 require (
-	github.com/dave/jennifer v1.7.1 // indirect
-	github.com/iancoleman/strcase v0.3.0 // indirect
+	github.com/dave/jennifer v1.7.1
+	github.com/iancoleman/strcase v0.3.0
+	golang.org/x/tools v0.28.0
+)
+
+require (
 	golang.org/x/mod v0.22.0 // indirect
 	golang.org/x/sync v0.10.0 // indirect
-	golang.org/x/tools v0.28.0 // indirect
 )
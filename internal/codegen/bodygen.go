@@ -0,0 +1,217 @@
+package codegen
+
+import (
+	"fmt"
+	"github.com/dave/jennifer/jen"
+	"github.com/softwaresale/lambdagen/internal/model"
+	"go/types"
+)
+
+// bodyDecoder is a way of decoding a request body into the body field
+type bodyDecoder int
+
+const (
+	bodyDecoderJSON bodyDecoder = iota // bodyDecoderJSON decodes application/json bodies
+	bodyDecoderForm                    // bodyDecoderForm decodes application/x-www-form-urlencoded bodies
+	bodyDecoderText                    // bodyDecoderText takes text/plain bodies as is
+	bodyDecoderRaw                     // bodyDecoderRaw takes the body bytes as is, regardless of content type
+)
+
+// contentType gets the pkg constant for the content type this decoder handles
+func (decoder bodyDecoder) contentType() jen.Code {
+	switch decoder {
+	case bodyDecoderForm:
+		return jen.Qual("github.com/softwaresale/lambdagen/pkg", "ContentTypeForm")
+	case bodyDecoderText:
+		return jen.Qual("github.com/softwaresale/lambdagen/pkg", "ContentTypeText")
+	default:
+		return jen.Qual("github.com/softwaresale/lambdagen/pkg", "ContentTypeJSON")
+	}
+}
+
+// bodyDecoders gets the decoders that can produce a body of the given type. The first decoder is used when the request
+// has no content type.
+func bodyDecoders(tp types.Type) []bodyDecoder {
+	if ptrTp, ok := tp.(*types.Pointer); ok {
+		tp = ptrTp.Elem()
+	}
+
+	switch underlying := tp.Underlying().(type) {
+	case *types.Slice:
+		if elem, ok := underlying.Elem().(*types.Basic); ok && elem.Kind() == types.Byte {
+			return []bodyDecoder{bodyDecoderRaw}
+		}
+
+	case *types.Basic:
+		if underlying.Kind() == types.String {
+			return []bodyDecoder{bodyDecoderText, bodyDecoderJSON}
+		}
+
+	case *types.Struct:
+		return []bodyDecoder{bodyDecoderJSON, bodyDecoderForm}
+
+	case *types.Map:
+		if key, ok := underlying.Key().Underlying().(*types.Basic); ok && key.Kind() == types.String {
+			return []bodyDecoder{bodyDecoderJSON, bodyDecoderForm}
+		}
+	}
+
+	return []bodyDecoder{bodyDecoderJSON}
+}
+
+// bodyNeedsMediaType checks if decoding a body of the given type depends on the request content type
+func bodyNeedsMediaType(tp types.Type) bool {
+	decoders := bodyDecoders(tp)
+	return len(decoders) > 1 || decoders[0] != bodyDecoderRaw
+}
+
+// formatBody decodes the request body into bodyVar with a decoder picked from the request content type. Base64 encoded
+// bodies are decoded first. Like other request variables, pointer bodies are optional and left nil if the request has
+// no body. Every other body is required.
+func (gen *ServiceGenerator) formatBody(group *jen.Group, bodyVar string) {
+	bodyDef := gen.method.Config.Body
+	_, optional := bodyDef.Type.(*types.Pointer)
+
+	bytesVar := "bodyBytes"
+	group.List(jen.Id(bytesVar), jen.Err()).Op(":=").Qual("github.com/softwaresale/lambdagen/pkg", "RequestBody").Call(
+		jen.Id(VariableRequest).Dot("Body"),
+		jen.Id(VariableRequest).Dot("IsBase64Encoded"),
+	)
+	CheckError(group, func(ifGroup *jen.Group) {
+		GenerateAPIError(ifGroup, 400, errorCodeInvalidBody, "request body is not valid base64")
+	})
+
+	if !optional {
+		group.If(jen.Len(jen.Id(bytesVar)).Op("==").Lit(0)).BlockFunc(func(group *jen.Group) {
+			GenerateAPIError(group, 400, errorCodeInvalidBody, "request body is required")
+		})
+	}
+
+	group.Var().Id(bodyVar).Add(TypeCode(bodyDef.Type))
+
+	decode := func(group *jen.Group) {
+		decoders := bodyDecoders(bodyDef.Type)
+		if len(decoders) == 1 && decoders[0] == bodyDecoderRaw {
+			formatBodyDecoder(group, bodyDecoderRaw, bodyDef.Type, bytesVar, bodyVar)
+			return
+		}
+
+		group.Switch(jen.Qual("github.com/softwaresale/lambdagen/pkg", "MediaType").Call(jen.Id(VariableHeaders))).BlockFunc(func(switchGroup *jen.Group) {
+			for idx, decoder := range decoders {
+				cases := []jen.Code{decoder.contentType()}
+				if idx == 0 {
+					cases = append([]jen.Code{jen.Lit("")}, cases...)
+				}
+
+				switchGroup.Case(cases...).BlockFunc(func(caseGroup *jen.Group) {
+					formatBodyDecoder(caseGroup, decoder, bodyDef.Type, bytesVar, bodyVar)
+				})
+			}
+
+			switchGroup.Default().BlockFunc(func(defaultGroup *jen.Group) {
+				GenerateAPIError(defaultGroup, 415, errorCodeUnsupportedMedia, "unsupported request content type")
+			})
+		})
+		CheckError(group, func(ifGroup *jen.Group) {
+			GenerateAPIError(ifGroup, 400, errorCodeInvalidBody, "malformed request body")
+		})
+	}
+
+	if optional {
+		group.If(jen.Len(jen.Id(bytesVar)).Op(">").Lit(0)).BlockFunc(decode)
+	} else {
+		decode(group)
+	}
+}
+
+// formatBodyDecoder decodes bytesVar into bodyVar with a single decoder. Decoding errors are left in err.
+func formatBodyDecoder(group *jen.Group, decoder bodyDecoder, tp types.Type, bytesVar, bodyVar string) {
+	switch decoder {
+	case bodyDecoderJSON:
+		group.Err().Op("=").Qual("encoding/json", "Unmarshal").Call(jen.Id(bytesVar), jen.Op("&").Id(bodyVar))
+
+	case bodyDecoderForm:
+		group.Err().Op("=").Qual("github.com/softwaresale/lambdagen/pkg", "DecodeForm").Call(jen.Id(bytesVar), jen.Op("&").Id(bodyVar))
+
+	default:
+		// text and raw bodies are a plain conversion
+		if ptrTp, ok := tp.(*types.Pointer); ok {
+			valueVar := bodyVar + "Value"
+			group.Id(valueVar).Op(":=").Add(TypeCode(ptrTp.Elem())).Parens(jen.Id(bytesVar))
+			group.Id(bodyVar).Op("=").Op("&").Id(valueVar)
+		} else {
+			group.Id(bodyVar).Op("=").Add(TypeCode(tp)).Parens(jen.Id(bytesVar))
+		}
+	}
+}
+
+// formatMultipartForm decodes a multipart/form-data body into the form variable. Any other content type is rejected.
+func (gen *ServiceGenerator) formatMultipartForm(group *jen.Group) {
+	bytesVar := "bodyBytes"
+	group.List(jen.Id(bytesVar), jen.Err()).Op(":=").Qual("github.com/softwaresale/lambdagen/pkg", "RequestBody").Call(
+		jen.Id(VariableRequest).Dot("Body"),
+		jen.Id(VariableRequest).Dot("IsBase64Encoded"),
+	)
+	CheckError(group, func(ifGroup *jen.Group) {
+		GenerateAPIError(ifGroup, 400, errorCodeInvalidBody, "request body is not valid base64")
+	})
+
+	group.If(jen.Qual("github.com/softwaresale/lambdagen/pkg", "MediaType").Call(jen.Id(VariableHeaders)).Op("!=").Qual("github.com/softwaresale/lambdagen/pkg", "ContentTypeMultipart")).BlockFunc(func(group *jen.Group) {
+		GenerateAPIError(group, 415, errorCodeUnsupportedMedia, "request body must be multipart/form-data")
+	})
+
+	group.List(jen.Id(VariableForm), jen.Err()).Op(":=").Qual("github.com/softwaresale/lambdagen/pkg", "ParseMultipartForm").Call(jen.Id(VariableHeaders), jen.Id(bytesVar))
+	CheckError(group, func(ifGroup *jen.Group) {
+		GenerateAPIError(ifGroup, 400, errorCodeInvalidBody, "malformed multipart body")
+	})
+}
+
+// formatFileVariable binds an uploaded file. Pointer files are optional, slices take every file uploaded under the name,
+// and plain files are required.
+func formatFileVariable(group *jen.Group, fileVar model.VariableDefinition) {
+	fileIdent := variableIdent(fileVar)
+
+	switch tp := fileVar.Type.(type) {
+	case *types.Slice:
+		group.Id(fileIdent).Op(":=").Id(VariableForm).Dot("Files").Index(jen.Lit(fileVar.Name))
+		if fileVar.HasOption(model.TagOptionMaxSize) {
+			elemIdent := fileIdent + "Elem"
+			group.For(jen.List(jen.Id("_"), jen.Id(elemIdent)).Op(":=").Range().Id(fileIdent)).BlockFunc(func(loop *jen.Group) {
+				formatFileSizeCheck(loop, fileVar, elemIdent)
+			})
+		}
+
+	case *types.Pointer:
+		valueIdent := fileIdent + "Value"
+		group.Var().Id(fileIdent).Add(TypeCode(tp))
+		group.If(
+			jen.List(jen.Id(valueIdent), jen.Id("ok")).Op(":=").Id(VariableForm).Dot("LookupFile").Call(jen.Lit(fileVar.Name)),
+			jen.Id("ok"),
+		).BlockFunc(func(group *jen.Group) {
+			formatFileSizeCheck(group, fileVar, valueIdent)
+			group.Id(fileIdent).Op("=").Op("&").Id(valueIdent)
+		})
+
+	default:
+		group.List(jen.Id(fileIdent), jen.Id("ok")).Op(":=").Id(VariableForm).Dot("LookupFile").Call(jen.Lit(fileVar.Name))
+		group.If(jen.Op("!").Id("ok")).BlockFunc(func(group *jen.Group) {
+			GenerateFieldError(group, errorCodeMissingParameter, fileVar.Name, fmt.Sprintf("file '%s' not found", fileVar.Name))
+		})
+		formatFileSizeCheck(group, fileVar, fileIdent)
+	}
+}
+
+// formatFileSizeCheck rejects a file larger than the max_size of its variable with a 413. Does nothing if the variable
+// has no max size.
+func formatFileSizeCheck(group *jen.Group, fileVar model.VariableDefinition, fileIdent string) {
+	maxSizeOption, ok := fileVar.Options[model.TagOptionMaxSize]
+	if !ok {
+		return
+	}
+
+	// already validated by the parser
+	maxSize, _ := model.ParseByteSize(maxSizeOption)
+	group.If(jen.Id(fileIdent).Dot("Size").Call().Op(">").Lit(maxSize)).BlockFunc(func(group *jen.Group) {
+		GenerateFieldErrorStatus(group, 413, errorCodeFileTooLarge, fileVar.Name, fmt.Sprintf("file '%s' is larger than %s", fileVar.Name, maxSizeOption))
+	})
+}
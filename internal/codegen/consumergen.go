@@ -0,0 +1,109 @@
+package codegen
+
+import (
+	"github.com/dave/jennifer/jen"
+	"github.com/softwaresale/lambdagen/internal/model"
+	"io"
+)
+
+const (
+	VariableEvent   = "event"
+	VariableMessage = "msg"
+)
+
+// TranslateConsumer generates a lambda that consumes batches of messages from an event source. Messages that fail are
+// reported as partial batch failures, so the rest of the batch isn't retried.
+func TranslateConsumer(output io.Writer, definition model.ServiceDefinition, consumer model.ConsumerDefinition) error {
+
+	unit := jen.NewFile("main")
+
+	// write the pre-amble
+	unit.HeaderComment("Code generated by lambdagen. DO NOT EDIT")
+
+	generator := ServiceGenerator{
+		def:        &definition,
+		handlerVar: VariableHandler,
+	}
+
+	generator.formatSharedState(unit.Group)
+	unit.Func().Id("init").Params().BlockFunc(func(group *jen.Group) {
+		cfgVar := generator.formatLoadConfig(group)
+		generator.formatServiceInit(group, cfgVar)
+	})
+
+	generator.formatConsumerHandler(unit.Group, consumer)
+	generator.formatMainFunc(unit.Group)
+
+	return unit.Render(output)
+}
+
+// formatConsumerHandler generates the lambda handler, which calls the consumer for every message of the batch
+func (gen *ServiceGenerator) formatConsumerHandler(group *jen.Group, consumer model.ConsumerDefinition) {
+	failuresVar := "failures"
+	failureVar := "failure"
+	responseVar := "response"
+	messageIDVar := "messageID"
+	idxVar := "idx"
+	processVar := "process"
+
+	records := jen.Id(VariableEvent).Dot("Records")
+
+	group.Func().Id(HandlerFunc).Params(
+		jen.Id(VariableContext).Qual("context", "Context"),
+		jen.Id(VariableEvent).Qual("github.com/aws/aws-lambda-go/events", "SQSEvent"),
+	).Parens(
+		jen.List(jen.Qual("github.com/aws/aws-lambda-go/events", "SQSEventResponse"), jen.Error()),
+	).BlockFunc(func(group *jen.Group) {
+		group.Id(processVar).Op(":=").Func().Params(
+			jen.Id(VariableContext).Qual("context", "Context"),
+			jen.Id(idxVar).Int(),
+		).Error().BlockFunc(func(group *jen.Group) {
+			body := records.Clone().Index(jen.Id(idxVar)).Dot("Body")
+			formatDecodeMessage(group, consumer, body)
+			group.Return(jen.Id(gen.handlerVar).Dot(consumer.ConsumerMethodName).Call(jen.Id(VariableContext), jen.Id(VariableMessage)))
+		})
+
+		// FIFO message groups have to stay in order, so nothing after a failed message is processed
+		group.Var().Id(failuresVar).Index().Qual("github.com/softwaresale/lambdagen/pkg", "BatchItemError")
+		group.If(
+			jen.Len(records.Clone()).Op(">").Lit(0).Op("&&").Qual("github.com/softwaresale/lambdagen/pkg", "IsFIFOQueue").Call(records.Clone().Index(jen.Lit(0)).Dot("EventSourceARN")),
+		).Block(
+			jen.Id(failuresVar).Op("=").Qual("github.com/softwaresale/lambdagen/pkg", "ProcessOrderedBatch").Call(jen.Id(VariableContext), jen.Len(records.Clone()), jen.Id(processVar)),
+		).Else().Block(
+			jen.Id(failuresVar).Op("=").Qual("github.com/softwaresale/lambdagen/pkg", "ProcessBatch").Call(jen.Id(VariableContext), jen.Len(records.Clone()), jen.Lit(consumer.Concurrency), jen.Id(processVar)),
+		)
+
+		// failed messages are retried by the event source, the rest of the batch is deleted
+		group.Var().Id(responseVar).Qual("github.com/aws/aws-lambda-go/events", "SQSEventResponse")
+		group.For(jen.List(jen.Id("_"), jen.Id(failureVar)).Op(":=").Range().Id(failuresVar)).Block(
+			jen.Id(messageIDVar).Op(":=").Add(records.Clone()).Index(jen.Id(failureVar).Dot("Index")).Dot("MessageId"),
+			jen.Qual("log", "Printf").Call(jen.Lit("message %s failed: %s"), jen.Id(messageIDVar), jen.Id(failureVar).Dot("Err")),
+			jen.Id(responseVar).Dot("BatchItemFailures").Op("=").Append(
+				jen.Id(responseVar).Dot("BatchItemFailures"),
+				jen.Qual("github.com/aws/aws-lambda-go/events", "SQSBatchItemFailure").Values(jen.Dict{
+					jen.Id("ItemIdentifier"): jen.Id(messageIDVar),
+				}),
+			),
+		)
+
+		group.Return(jen.List(jen.Id(responseVar), jen.Nil()))
+	})
+}
+
+// formatDecodeMessage decodes a message body into the message variable. Messages that can't be decoded fail.
+func formatDecodeMessage(group *jen.Group, consumer model.ConsumerDefinition, body *jen.Statement) {
+	switch consumer.Encoding {
+	case model.MessageEncodingString:
+		group.Id(VariableMessage).Op(":=").Add(body)
+
+	case model.MessageEncodingBytes:
+		group.Id(VariableMessage).Op(":=").Index().Byte().Parens(body)
+
+	default:
+		group.Var().Id(VariableMessage).Add(TypeCode(consumer.MessageType))
+		group.Err().Op(":=").Qual("encoding/json", "Unmarshal").Call(jen.Index().Byte().Parens(body), jen.Op("&").Id(VariableMessage))
+		CheckError(group, func(ifGroup *jen.Group) {
+			ifGroup.Return(jen.Qual("fmt", "Errorf").Call(jen.Lit("malformed message body: %w"), jen.Err()))
+		})
+	}
+}
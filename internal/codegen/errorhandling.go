@@ -2,34 +2,67 @@ package codegen
 
 import "github.com/dave/jennifer/jen"
 
+// error codes declared in pkg that generated code responds with
+const (
+	errorCodeMissingParameter = "ErrorCodeMissingParameter"
+	errorCodeInvalidParameter = "ErrorCodeInvalidParameter"
+	errorCodeInvalidBody      = "ErrorCodeInvalidBody"
+	errorCodeUnsupportedMedia = "ErrorCodeUnsupportedMedia"
+	errorCodeFileTooLarge     = "ErrorCodeFileTooLarge"
+	errorCodeInternal         = "ErrorCodeInternal"
+)
+
 func CheckError(group *jen.Group, GenError func(group *jen.Group)) {
 	group.If(jen.Err().Op("!=").Nil()).BlockFunc(GenError)
 }
 
-func GenerateAPIError(group *jen.Group, status int, message string) {
+// GenerateAPIError responds with a pkg.APIError body. err is passed along as the cause, so it must be in scope.
+func GenerateAPIError(group *jen.Group, status int, code, message string) {
 
 	// create an API
 	apiErrVar := "apiErr"
-	group.Id(apiErrVar).Op(":=").Qual("github.com/softwaresale/lambdagen/pkg", "APIError").Values(jen.Dict{
-		jen.Id("Message"): jen.Lit(message),
-		jen.Id("Error"):   jen.Err(),
-	})
+	group.Id(apiErrVar).Op(":=").Qual("github.com/softwaresale/lambdagen/pkg", "NewAPIError").Call(
+		jen.Qual("github.com/softwaresale/lambdagen/pkg", code),
+		jen.Lit(message),
+		jen.Id(VariableRequestID),
+		jen.Err(),
+	)
 
-	responseBodyVar := "errorResponseBody"
+	generateErrorResponse(group, jen.Lit(status), jen.Id(apiErrVar))
+}
 
-	group.List(group.Id(responseBodyVar), jen.Err()).Op(":=").Qual("encoding/json", "Marshal").Call(jen.Id(apiErrVar))
-	group.If(jen.Err().Op("!=").Nil()).Block(
-		jen.Return(jen.List(
-			jen.Qual("github.com/aws/aws-lambda-go/events", "APIGatewayProxyResponse").Values(jen.Dict{}),
-			jen.Err(),
-		)),
+// GenerateFieldError responds with a 400 pkg.APIError body that names the rejected request field
+func GenerateFieldError(group *jen.Group, code, field, message string) {
+	GenerateFieldErrorStatus(group, 400, code, field, message)
+}
+
+// GenerateFieldErrorStatus responds with a pkg.APIError body that names the rejected request field
+func GenerateFieldErrorStatus(group *jen.Group, status int, code, field, message string) {
+	apiErrVar := "apiErr"
+	group.Id(apiErrVar).Op(":=").Qual("github.com/softwaresale/lambdagen/pkg", "NewFieldAPIError").Call(
+		jen.Qual("github.com/softwaresale/lambdagen/pkg", code),
+		jen.Lit(field),
+		jen.Lit(message),
+		jen.Id(VariableRequestID),
+		jen.Err(),
 	)
 
-	group.Return(jen.List(
-		jen.Qual("github.com/aws/aws-lambda-go/events", "APIGatewayProxyResponse").Values(jen.Dict{
-			jen.Id("StatusCode"): jen.Lit(status),
-			jen.Id("Body"):       jen.String().Parens(jen.Id(responseBodyVar)),
-		}),
-		jen.Nil(),
-	))
+	generateErrorResponse(group, jen.Lit(status), jen.Id(apiErrVar))
+}
+
+// GenerateHandlerError responds to an error returned by a handler. A pkg.HTTPError anywhere in the error chain picks
+// the status, anything else is an internal error.
+func GenerateHandlerError(group *jen.Group) {
+	httpErrVar := "httpErr"
+	group.Var().Id(httpErrVar).Op("*").Qual("github.com/softwaresale/lambdagen/pkg", "HTTPError")
+	group.If(jen.Qual("errors", "As").Call(jen.Err(), jen.Op("&").Id(httpErrVar))).BlockFunc(func(group *jen.Group) {
+		generateErrorResponse(group, jen.Id(httpErrVar).Dot("StatusCode").Call(), jen.Id(httpErrVar).Dot("APIError").Call(jen.Id(VariableRequestID)))
+	})
+
+	GenerateAPIError(group, 500, errorCodeInternal, "error while processing handler")
+}
+
+// generateErrorResponse returns an APIError body with the given status through the error response function
+func generateErrorResponse(group *jen.Group, status jen.Code, apiErr jen.Code) {
+	group.Return(jen.Id(ErrorResponseFunc).Call(jen.Id(VariableRequest), status, apiErr))
 }
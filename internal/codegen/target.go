@@ -0,0 +1,406 @@
+package codegen
+
+import (
+	"fmt"
+	"github.com/dave/jennifer/jen"
+	"github.com/softwaresale/lambdagen/internal/model"
+)
+
+// responseParts are the parts of a response that every target can send
+type responseParts struct {
+	Status      jen.Code // Status is the status code
+	Headers     jen.Code // Headers is an http.Header of response headers, or nil if there are none
+	Cookies     jen.Code // Cookies is a []*http.Cookie to set, or nil if there are none
+	ContentType jen.Code // ContentType is used if Headers doesn't have one, or nil if there is no body
+	Body        jen.Code // Body is the string body, or nil if there is no body
+	Base64      bool     // Base64 is set if Body is base64 encoded
+}
+
+// eventTarget generates the code that differs between the event sources that can invoke a lambda
+type eventTarget interface {
+	// RequestType gets the type of the event handlers receive
+	RequestType() *jen.Statement
+	// ResponseType gets the type of the response handlers return
+	ResponseType() *jen.Statement
+	// RequestID gets the ID of the request, which is included in error bodies
+	RequestID() *jen.Statement
+	// RequestPath gets the path that was requested
+	RequestPath() *jen.Statement
+	// RoutePath gets the path that routes are matched against, without any stage prefix
+	RoutePath() *jen.Statement
+	// Method gets the HTTP method of the request
+	Method() *jen.Statement
+	// PathParameters gets the values of the path placeholders as a map[string]string
+	PathParameters() *jen.Statement
+	// Query gets the query parameters as url.Values
+	Query() *jen.Statement
+	// Headers gets the request headers as an http.Header
+	Headers() *jen.Statement
+	// Claims declares claimsVar as a map[string]string of the authorizer claims, which is nil if there are none
+	Claims(group *jen.Group, claimsVar string)
+	// Response builds a response value
+	Response(parts responseParts) *jen.Statement
+}
+
+// newEventTarget gets the generator for the target of a service. method is nil when generating a routed lambda that
+// serves every handler of the service.
+func newEventTarget(definition *model.ServiceDefinition, method *model.HandlerDefinition) eventTarget {
+	switch target := definition.Target; target {
+	case model.TargetHTTPAPI:
+		return httpAPITarget{}
+	case model.TargetALB:
+		var pathTemplate string
+		if method != nil {
+			pathTemplate = definition.HandlerPath(*method)
+		}
+
+		return albTarget{
+			pathTemplate:      pathTemplate,
+			multiValueHeaders: definition.ALBMultiValueHeaders(),
+		}
+	case model.TargetFunctionURL:
+		return functionURLTarget{}
+	case model.TargetRestAPI, "":
+		return restAPITarget{}
+	default:
+		panic(fmt.Sprintf("unsupported target: %s", target))
+	}
+}
+
+// restAPITarget generates handlers for API Gateway REST APIs, which send payload format 1.0
+type restAPITarget struct{}
+
+func (restAPITarget) RequestType() *jen.Statement {
+	return jen.Qual("github.com/aws/aws-lambda-go/events", "APIGatewayProxyRequest")
+}
+
+func (restAPITarget) ResponseType() *jen.Statement {
+	return jen.Qual("github.com/aws/aws-lambda-go/events", "APIGatewayProxyResponse")
+}
+
+func (restAPITarget) RequestID() *jen.Statement {
+	return jen.Id(VariableRequest).Dot("RequestContext").Dot("RequestID")
+}
+
+func (restAPITarget) RequestPath() *jen.Statement {
+	return jen.Id(VariableRequest).Dot("Path")
+}
+
+func (restAPITarget) RoutePath() *jen.Statement {
+	return jen.Id(VariableRequest).Dot("Path")
+}
+
+func (restAPITarget) Method() *jen.Statement {
+	return jen.Id(VariableRequest).Dot("HTTPMethod")
+}
+
+func (restAPITarget) PathParameters() *jen.Statement {
+	return jen.Id(VariableRequest).Dot("PathParameters")
+}
+
+func (restAPITarget) Query() *jen.Statement {
+	// merge the single and multi-value parameters so that repeated keys are available
+	return jen.Qual("github.com/softwaresale/lambdagen/pkg", "RequestQuery").Call(
+		jen.Id(VariableRequest).Dot("QueryStringParameters"),
+		jen.Id(VariableRequest).Dot("MultiValueQueryStringParameters"),
+	)
+}
+
+func (restAPITarget) Headers() *jen.Statement {
+	return jen.Qual("github.com/softwaresale/lambdagen/pkg", "RequestHeaders").Call(
+		jen.Id(VariableRequest).Dot("Headers"),
+		jen.Id(VariableRequest).Dot("MultiValueHeaders"),
+	)
+}
+
+func (restAPITarget) Claims(group *jen.Group, claimsVar string) {
+	group.Id(claimsVar).Op(":=").Qual("github.com/softwaresale/lambdagen/pkg", "RESTAuthorizerClaims").Call(
+		jen.Id(VariableRequest).Dot("RequestContext").Dot("Authorizer"),
+	)
+}
+
+func (target restAPITarget) Response(parts responseParts) *jen.Statement {
+	values := jen.Dict{
+		jen.Id("StatusCode"): parts.Status,
+	}
+
+	if parts.Headers != nil || parts.Cookies != nil {
+		// cookies become Set-Cookie headers
+		values[jen.Id("MultiValueHeaders")] = jen.Qual("github.com/softwaresale/lambdagen/pkg", "MultiValueHeaders").Call(
+			orNil(parts.Headers),
+			orNil(parts.Cookies),
+			orEmptyString(parts.ContentType),
+		)
+	} else if parts.ContentType != nil {
+		values[jen.Id("Headers")] = jen.Map(jen.String()).String().Values(jen.Dict{
+			jen.Lit("Content-Type"): parts.ContentType,
+		})
+	}
+
+	addResponseBody(values, parts)
+	return target.ResponseType().Values(values)
+}
+
+// httpAPITarget generates handlers for API Gateway HTTP APIs, which send payload format 2.0
+type httpAPITarget struct{}
+
+func (httpAPITarget) RequestType() *jen.Statement {
+	return jen.Qual("github.com/aws/aws-lambda-go/events", "APIGatewayV2HTTPRequest")
+}
+
+func (httpAPITarget) ResponseType() *jen.Statement {
+	return jen.Qual("github.com/aws/aws-lambda-go/events", "APIGatewayV2HTTPResponse")
+}
+
+func (httpAPITarget) RequestID() *jen.Statement {
+	return jen.Id(VariableRequest).Dot("RequestContext").Dot("RequestID")
+}
+
+func (httpAPITarget) RequestPath() *jen.Statement {
+	return jen.Id(VariableRequest).Dot("RawPath")
+}
+
+func (httpAPITarget) RoutePath() *jen.Statement {
+	// the raw path starts with the stage unless it's the $default stage
+	return jen.Qual("github.com/softwaresale/lambdagen/pkg", "StripStage").Call(
+		jen.Id(VariableRequest).Dot("RawPath"),
+		jen.Id(VariableRequest).Dot("RequestContext").Dot("Stage"),
+	)
+}
+
+func (httpAPITarget) Method() *jen.Statement {
+	return jen.Id(VariableRequest).Dot("RequestContext").Dot("HTTP").Dot("Method")
+}
+
+func (httpAPITarget) PathParameters() *jen.Statement {
+	return jen.Id(VariableRequest).Dot("PathParameters")
+}
+
+func (httpAPITarget) Query() *jen.Statement {
+	// QueryStringParameters joins repeated keys with commas, so parse the raw query instead
+	return jen.Qual("github.com/softwaresale/lambdagen/pkg", "ParseRawQuery").Call(jen.Id(VariableRequest).Dot("RawQueryString"))
+}
+
+func (httpAPITarget) Headers() *jen.Statement {
+	return jen.Qual("github.com/softwaresale/lambdagen/pkg", "HTTPAPIRequestHeaders").Call(
+		jen.Id(VariableRequest).Dot("Headers"),
+		jen.Id(VariableRequest).Dot("Cookies"),
+	)
+}
+
+func (httpAPITarget) Claims(group *jen.Group, claimsVar string) {
+	// only JWT authorizers have claims
+	authorizer := jen.Id(VariableRequest).Dot("RequestContext").Dot("Authorizer")
+	group.Var().Id(claimsVar).Map(jen.String()).String()
+	group.If(authorizer.Clone().Op("!=").Nil().Op("&&").Add(authorizer.Clone()).Dot("JWT").Op("!=").Nil()).Block(
+		jen.Id(claimsVar).Op("=").Add(authorizer.Clone()).Dot("JWT").Dot("Claims"),
+	)
+}
+
+func (target httpAPITarget) Response(parts responseParts) *jen.Statement {
+	values := jen.Dict{
+		jen.Id("StatusCode"): parts.Status,
+	}
+
+	if parts.Headers != nil {
+		values[jen.Id("Headers")] = jen.Qual("github.com/softwaresale/lambdagen/pkg", "SingleValueHeaders").Call(parts.Headers, orEmptyString(parts.ContentType))
+	} else if parts.ContentType != nil {
+		values[jen.Id("Headers")] = jen.Map(jen.String()).String().Values(jen.Dict{
+			jen.Lit("Content-Type"): parts.ContentType,
+		})
+	}
+
+	// HTTP APIs send cookies separately from the headers
+	if parts.Cookies != nil {
+		values[jen.Id("Cookies")] = jen.Qual("github.com/softwaresale/lambdagen/pkg", "CookieValues").Call(parts.Cookies)
+	}
+
+	addResponseBody(values, parts)
+	return target.ResponseType().Values(values)
+}
+
+// albTarget generates handlers for Application Load Balancer target groups
+type albTarget struct {
+	pathTemplate      string // pathTemplate is the full handler path, which path parameters are matched against
+	multiValueHeaders bool   // multiValueHeaders is set if the target group sends and expects multi-value headers
+}
+
+func (albTarget) RequestType() *jen.Statement {
+	return jen.Qual("github.com/aws/aws-lambda-go/events", "ALBTargetGroupRequest")
+}
+
+func (albTarget) ResponseType() *jen.Statement {
+	return jen.Qual("github.com/aws/aws-lambda-go/events", "ALBTargetGroupResponse")
+}
+
+func (albTarget) RequestID() *jen.Statement {
+	return jen.Qual("github.com/softwaresale/lambdagen/pkg", "ALBTraceID").Call(
+		jen.Id(VariableRequest).Dot("Headers"),
+		jen.Id(VariableRequest).Dot("MultiValueHeaders"),
+	)
+}
+
+func (albTarget) RequestPath() *jen.Statement {
+	return jen.Id(VariableRequest).Dot("Path")
+}
+
+func (albTarget) RoutePath() *jen.Statement {
+	return jen.Id(VariableRequest).Dot("Path")
+}
+
+func (albTarget) Method() *jen.Statement {
+	return jen.Id(VariableRequest).Dot("HTTPMethod")
+}
+
+func (target albTarget) PathParameters() *jen.Statement {
+	// load balancers route by path pattern, so placeholders are matched here
+	return jen.Qual("github.com/softwaresale/lambdagen/pkg", "MatchPath").Call(jen.Lit(target.pathTemplate), jen.Id(VariableRequest).Dot("Path"))
+}
+
+func (albTarget) Query() *jen.Statement {
+	return jen.Qual("github.com/softwaresale/lambdagen/pkg", "ALBRequestQuery").Call(
+		jen.Id(VariableRequest).Dot("QueryStringParameters"),
+		jen.Id(VariableRequest).Dot("MultiValueQueryStringParameters"),
+	)
+}
+
+func (albTarget) Headers() *jen.Statement {
+	return jen.Qual("github.com/softwaresale/lambdagen/pkg", "RequestHeaders").Call(
+		jen.Id(VariableRequest).Dot("Headers"),
+		jen.Id(VariableRequest).Dot("MultiValueHeaders"),
+	)
+}
+
+func (albTarget) Claims(group *jen.Group, claimsVar string) {
+	// load balancers don't authorize requests
+	group.Var().Id(claimsVar).Map(jen.String()).String()
+}
+
+func (target albTarget) Response(parts responseParts) *jen.Statement {
+	values := jen.Dict{
+		jen.Id("StatusCode"):        parts.Status,
+		jen.Id("StatusDescription"): jen.Qual("github.com/softwaresale/lambdagen/pkg", "StatusDescription").Call(parts.Status),
+	}
+
+	// the target group only reads the header field for its mode
+	if target.multiValueHeaders {
+		if parts.Headers != nil || parts.Cookies != nil || parts.ContentType != nil {
+			values[jen.Id("MultiValueHeaders")] = jen.Qual("github.com/softwaresale/lambdagen/pkg", "MultiValueHeaders").Call(
+				orNil(parts.Headers),
+				orNil(parts.Cookies),
+				orEmptyString(parts.ContentType),
+			)
+		}
+	} else if parts.Headers != nil || parts.Cookies != nil {
+		values[jen.Id("Headers")] = jen.Qual("github.com/softwaresale/lambdagen/pkg", "ALBSingleValueHeaders").Call(
+			orNil(parts.Headers),
+			orNil(parts.Cookies),
+			orEmptyString(parts.ContentType),
+		)
+	} else if parts.ContentType != nil {
+		values[jen.Id("Headers")] = jen.Map(jen.String()).String().Values(jen.Dict{
+			jen.Lit("Content-Type"): parts.ContentType,
+		})
+	}
+
+	addResponseBody(values, parts)
+	return target.ResponseType().Values(values)
+}
+
+// functionURLTarget generates handlers for Lambda function URLs. Function URLs send every request to one lambda, so
+// handlers are dispatched by the generated router.
+type functionURLTarget struct{}
+
+func (functionURLTarget) RequestType() *jen.Statement {
+	return jen.Qual("github.com/aws/aws-lambda-go/events", "LambdaFunctionURLRequest")
+}
+
+func (functionURLTarget) ResponseType() *jen.Statement {
+	return jen.Qual("github.com/aws/aws-lambda-go/events", "LambdaFunctionURLResponse")
+}
+
+func (functionURLTarget) RequestID() *jen.Statement {
+	return jen.Id(VariableRequest).Dot("RequestContext").Dot("RequestID")
+}
+
+func (functionURLTarget) RequestPath() *jen.Statement {
+	return jen.Id(VariableRequest).Dot("RawPath")
+}
+
+func (functionURLTarget) RoutePath() *jen.Statement {
+	return jen.Id(VariableRequest).Dot("RawPath")
+}
+
+func (functionURLTarget) Method() *jen.Statement {
+	return jen.Id(VariableRequest).Dot("RequestContext").Dot("HTTP").Dot("Method")
+}
+
+func (functionURLTarget) PathParameters() *jen.Statement {
+	// function URLs don't route, so placeholders are matched by the router
+	return jen.Id(VariableRouteParams)
+}
+
+func (functionURLTarget) Query() *jen.Statement {
+	return jen.Qual("github.com/softwaresale/lambdagen/pkg", "ParseRawQuery").Call(jen.Id(VariableRequest).Dot("RawQueryString"))
+}
+
+func (functionURLTarget) Headers() *jen.Statement {
+	return jen.Qual("github.com/softwaresale/lambdagen/pkg", "HTTPAPIRequestHeaders").Call(
+		jen.Id(VariableRequest).Dot("Headers"),
+		jen.Id(VariableRequest).Dot("Cookies"),
+	)
+}
+
+func (functionURLTarget) Claims(group *jen.Group, claimsVar string) {
+	// function URLs only authorize with IAM, which has no claims
+	group.Var().Id(claimsVar).Map(jen.String()).String()
+}
+
+func (target functionURLTarget) Response(parts responseParts) *jen.Statement {
+	values := jen.Dict{
+		jen.Id("StatusCode"): parts.Status,
+	}
+
+	if parts.Headers != nil {
+		values[jen.Id("Headers")] = jen.Qual("github.com/softwaresale/lambdagen/pkg", "SingleValueHeaders").Call(parts.Headers, orEmptyString(parts.ContentType))
+	} else if parts.ContentType != nil {
+		values[jen.Id("Headers")] = jen.Map(jen.String()).String().Values(jen.Dict{
+			jen.Lit("Content-Type"): parts.ContentType,
+		})
+	}
+
+	// function URLs send cookies separately from the headers
+	if parts.Cookies != nil {
+		values[jen.Id("Cookies")] = jen.Qual("github.com/softwaresale/lambdagen/pkg", "CookieValues").Call(parts.Cookies)
+	}
+
+	addResponseBody(values, parts)
+	return target.ResponseType().Values(values)
+}
+
+// addResponseBody adds the body fields that every response type shares
+func addResponseBody(values jen.Dict, parts responseParts) {
+	if parts.Body != nil {
+		values[jen.Id("Body")] = parts.Body
+	}
+
+	if parts.Base64 {
+		values[jen.Id("IsBase64Encoded")] = jen.True()
+	}
+}
+
+func orNil(code jen.Code) jen.Code {
+	if code == nil {
+		return jen.Nil()
+	}
+
+	return code
+}
+
+func orEmptyString(code jen.Code) jen.Code {
+	if code == nil {
+		return jen.Lit("")
+	}
+
+	return code
+}
@@ -0,0 +1,66 @@
+package rest
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/softwaresale/lambdagen/pkg"
+)
+
+// lambdagen:service :: base_path=/api
+type OrderService struct{}
+
+// lambdagen:service_init
+func NewOrderService(cfg aws.Config) (*OrderService, error) {
+	return &OrderService{}, nil
+}
+
+type OrderID = string
+
+type Cents int64
+
+type Region string
+
+// lambdagen:converter
+func ParseRegion(raw string) (Region, error) {
+	return Region(raw), nil
+}
+
+type Regions []Region
+
+type Order struct {
+	ID    OrderID `json:"id"`
+	Total Cents   `json:"total"`
+}
+
+type GetOrderConfig struct {
+	OrderID OrderID    `lambdagen:"pathvar"`
+	Region  Region     `lambdagen:"queryvar,region"`
+	Since   *time.Time `lambdagen:"header,If-Modified-Since"`
+	Limit   Cents      `lambdagen:"queryvar,limit,default=100"`
+	Tags    []string   `lambdagen:"queryvar,tag"`
+	IDs     []OrderID  `lambdagen:"queryvar,id,csv"`
+	Subject *string    `lambdagen:"claim,sub"`
+	Proxies []string   `lambdagen:"header,X-Forwarded-For,csv"`
+	Within  Regions    `lambdagen:"queryvar,within,csv"`
+}
+
+// lambdagen:handler :: GET /orders/{orderId}
+func (s *OrderService) GetOrder(ctx context.Context, cfg GetOrderConfig) (Order, error) {
+	return Order{ID: cfg.OrderID}, nil
+}
+
+type CreateOrderConfig struct {
+	Order Order `lambdagen:"body"`
+}
+
+// lambdagen:handler :: POST /orders status=201
+func (s *OrderService) CreateOrder(ctx context.Context, cfg CreateOrderConfig) (*pkg.Response[Order], error) {
+	return nil, nil
+}
+
+// lambdagen:consumer :: sqs concurrency=2
+func (s *OrderService) OrderPlaced(ctx context.Context, msg Order) error {
+	return nil
+}
@@ -0,0 +1,40 @@
+package routed
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// lambdagen:service :: target=function-url auth_type=NONE
+type NoteService struct{}
+
+// lambdagen:service_init
+func NewNoteService(cfg aws.Config) (*NoteService, error) {
+	return &NoteService{}, nil
+}
+
+type Note struct {
+	ID   int    `json:"id"`
+	Text string `json:"text"`
+}
+
+type NoteConfig struct {
+	ID      int    `lambdagen:"pathvar"`
+	Version *uint8 `lambdagen:"header,X-Version"`
+}
+
+// lambdagen:handler :: GET /notes/{id}
+func (s *NoteService) GetNote(ctx context.Context, cfg NoteConfig) (Note, error) {
+	return Note{ID: cfg.ID}, nil
+}
+
+// lambdagen:handler :: DELETE /notes/{id}
+func (s *NoteService) DeleteNote(ctx context.Context, cfg NoteConfig) error {
+	return nil
+}
+
+// lambdagen:handler :: GET /notes/latest
+func (s *NoteService) LatestNote(ctx context.Context) (Note, error) {
+	return Note{}, nil
+}
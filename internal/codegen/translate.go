@@ -9,10 +9,20 @@ import (
 )
 
 const (
-	VariableHandler = "handler"
-	VariableRequest = "request"
-	VariableContext = "ctx"
-	HandlerFunc     = "HandleRequest"
+	VariableHandler     = "handler"
+	VariableRequest     = "request"
+	VariableContext     = "ctx"
+	VariableHeaders     = "headers"
+	VariableQuery       = "query"
+	VariableForm        = "form"
+	VariableClaims      = "claims"
+	VariablePath        = "pathParams"
+	VariableRouteParams = "routeParams"
+	VariableRequestID   = "requestID"
+	VariableRoutes      = "routes"
+	HandlerFunc         = "HandleRequest"
+	ErrorResponseFunc   = "errorResponse"
+	RouteHandlerType    = "routeHandler"
 )
 
 func TranslateHandler(output io.Writer, definition model.ServiceDefinition, method model.HandlerDefinition) error {
@@ -24,21 +34,104 @@ func TranslateHandler(output io.Writer, definition model.ServiceDefinition, meth
 
 	// generate the shared state
 	generator := ServiceGenerator{
-		def:    &definition,
-		method: &method,
+		def:        &definition,
+		method:     &method,
+		target:     newEventTarget(&definition, &method),
+		handlerVar: VariableHandler,
 	}
 
 	generator.formatSharedState(unit.Group)
 	generator.formatInitFunc(unit.Group)
+	generator.formatErrorResponseFunc(unit.Group)
 	generator.formatHandler(unit.Group)
 	generator.formatMainFunc(unit.Group)
 
 	return unit.Render(output)
 }
 
+// RoutedService is a service and the handlers of it that a routed lambda serves
+type RoutedService struct {
+	Definition *model.ServiceDefinition
+	Handlers   []model.HandlerDefinition
+}
+
+// TranslateRouter generates a single lambda that serves the handlers of every given service. Requests are dispatched
+// to handlers by a generated router. The services must share a target and error config.
+func TranslateRouter(output io.Writer, services []RoutedService) error {
+
+	unit := jen.NewFile("main")
+
+	// write the pre-amble
+	unit.HeaderComment("Code generated by lambdagen. DO NOT EDIT")
+
+	// handlers are qualified by service once there is more than one
+	qualified := len(services) > 1
+	generators := make([]*ServiceGenerator, 0, len(services))
+	for _, service := range services {
+		generator := &ServiceGenerator{
+			def:        service.Definition,
+			target:     newEventTarget(service.Definition, nil),
+			handlerVar: VariableHandler,
+			routed:     true,
+		}
+
+		if qualified {
+			generator.handlerVar = VariableHandler + serviceName(service.Definition)
+		}
+
+		generator.formatSharedState(unit.Group)
+		generators = append(generators, generator)
+	}
+
+	// every service shares the config and error handling
+	router := generators[0]
+	unit.Func().Id("init").Params().BlockFunc(func(group *jen.Group) {
+		cfgVar := router.formatLoadConfig(group)
+		router.formatErrorConfig(group)
+
+		for _, generator := range generators {
+			generator.formatServiceInit(group, cfgVar)
+		}
+	})
+
+	router.formatErrorResponseFunc(unit.Group)
+	router.formatRouteHandlerType(unit.Group)
+
+	var routes []jen.Code
+	for idx, service := range services {
+		generator := generators[idx]
+		for methodIdx := range service.Handlers {
+			method := &service.Handlers[methodIdx]
+			funcName := routeHandlerFuncName(service.Definition, *method, qualified)
+
+			generator.method = method
+			generator.formatHandlerFunc(unit.Group, funcName)
+
+			routes = append(routes, jen.Values(jen.Dict{
+				jen.Id("Methods"): jen.Index().String().ValuesFunc(func(group *jen.Group) {
+					for _, httpMethod := range method.Methods {
+						group.Lit(httpMethod)
+					}
+				}),
+				jen.Id("Path"):    jen.Lit(service.Definition.HandlerPath(*method)),
+				jen.Id("Handler"): jen.Id(funcName),
+			}))
+		}
+	}
+
+	unit.Var().Id(VariableRoutes).Op("=").Index().Qual("github.com/softwaresale/lambdagen/pkg", "Route").Types(jen.Id(RouteHandlerType)).Values(routes...)
+	router.formatRouter(unit.Group)
+	router.formatMainFunc(unit.Group)
+
+	return unit.Render(output)
+}
+
 type ServiceGenerator struct {
-	def    *model.ServiceDefinition
-	method *model.HandlerDefinition
+	def        *model.ServiceDefinition
+	method     *model.HandlerDefinition
+	target     eventTarget
+	handlerVar string // handlerVar is the variable that holds the service instance
+	routed     bool   // routed is set if handler functions are called by a generated router
 }
 
 func (gen *ServiceGenerator) formatSharedState(group *jen.Group) {
@@ -51,85 +144,329 @@ func (gen *ServiceGenerator) formatSharedState(group *jen.Group) {
 	pkg := namedTp.Obj().Pkg().Path()
 	name := namedTp.Obj().Name()
 
-	group.Var().Id(VariableHandler).Op("*").Qual(pkg, name)
+	group.Var().Id(gen.handlerVar).Op("*").Qual(pkg, name)
 }
 
 func (gen *ServiceGenerator) formatInitFunc(group *jen.Group) {
 	group.Func().Id("init").Params().BlockFunc(func(group *jen.Group) {
-		// load the config
-		cfgVar := "cfg"
-		group.List(jen.Id(cfgVar), jen.Err()).Op(":=").Qual("github.com/aws/aws-sdk-go-v2/config", "LoadDefaultConfig").Call(jen.Qual("context", "TODO").Call())
-		CheckError(group, func(group *jen.Group) {
-			group.Panic(jen.Err())
-		})
+		cfgVar := gen.formatLoadConfig(group)
+		gen.formatErrorConfig(group)
+		gen.formatServiceInit(group, cfgVar)
+	})
+}
+
+// formatLoadConfig loads the AWS config that services are initialized with, and returns the variable holding it
+func (gen *ServiceGenerator) formatLoadConfig(group *jen.Group) string {
+	cfgVar := "cfg"
+	group.List(jen.Id(cfgVar), jen.Err()).Op(":=").Qual("github.com/aws/aws-sdk-go-v2/config", "LoadDefaultConfig").Call(jen.Qual("context", "TODO").Call())
+	CheckError(group, func(group *jen.Group) {
+		group.Panic(jen.Err())
+	})
+
+	return cfgVar
+}
+
+// formatErrorConfig configures how errors are sent
+func (gen *ServiceGenerator) formatErrorConfig(group *jen.Group) {
+	// internal error text is hidden from clients unless the service opts in
+	if gen.def.Config["expose_errors"] == "true" {
+		group.Qual("github.com/softwaresale/lambdagen/pkg", "ExposeErrorCauses").Op("=").True()
+	}
+
+	// errors are APIError bodies unless the service asks for problem details
+	if gen.def.Config["errors"] == "problem" {
+		group.Qual("github.com/softwaresale/lambdagen/pkg", "ActiveErrorFormat").Op("=").Qual("github.com/softwaresale/lambdagen/pkg", "ErrorFormatProblem")
+	}
+
+	if problemTypes, ok := gen.def.Config["problem_types"]; ok {
+		group.Qual("github.com/softwaresale/lambdagen/pkg", "ProblemTypeBase").Op("=").Lit(problemTypes)
+	}
+}
+
+// formatServiceInit creates the service instance
+func (gen *ServiceGenerator) formatServiceInit(group *jen.Group, cfgVar string) {
+	group.List(jen.Id(gen.handlerVar), jen.Err()).Op("=").Qual(gen.def.Init.Pkg().Path(), gen.def.Init.Name()).Call(jen.Id(cfgVar))
+	CheckError(group, func(group *jen.Group) {
+		group.Panic(jen.Err())
+	})
+}
 
-		group.List(jen.Id(VariableHandler), jen.Err()).Op("=").Qual(gen.def.Init.Pkg().Path(), gen.def.Init.Name()).Call(jen.Id(cfgVar))
-		CheckError(group, func(group *jen.Group) {
-			group.Panic(jen.Err())
+// formatErrorResponseFunc generates the function that every error path returns through. It encodes an APIError in the
+// service's error format.
+func (gen *ServiceGenerator) formatErrorResponseFunc(group *jen.Group) {
+	statusVar := "status"
+	apiErrVar := "apiErr"
+	responseBodyVar := "responseBody"
+	contentTypeVar := "contentType"
+
+	group.Func().Id(ErrorResponseFunc).Params(
+		jen.Id(VariableRequest).Add(gen.target.RequestType()),
+		jen.Id(statusVar).Int(),
+		jen.Id(apiErrVar).Qual("github.com/softwaresale/lambdagen/pkg", "APIError"),
+	).Parens(
+		jen.List(gen.target.ResponseType(), jen.Error()),
+	).BlockFunc(func(group *jen.Group) {
+		group.List(jen.Id(responseBodyVar), jen.Id(contentTypeVar), jen.Err()).Op(":=").Qual("github.com/softwaresale/lambdagen/pkg", "MarshalAPIError").Call(
+			jen.Id(statusVar),
+			jen.Id(apiErrVar),
+			gen.target.RequestPath(),
+		)
+		CheckError(group, func(ifGroup *jen.Group) {
+			ifGroup.Return(jen.List(gen.target.ResponseType().Values(), jen.Err()))
 		})
+
+		group.Return(jen.List(
+			gen.target.Response(responseParts{
+				Status:      jen.Id(statusVar),
+				ContentType: jen.Id(contentTypeVar),
+				Body:        jen.String().Parens(jen.Id(responseBodyVar)),
+			}),
+			jen.Nil(),
+		))
 	})
 }
 
 func (gen *ServiceGenerator) formatHandler(group *jen.Group) {
-	group.Func().Id(HandlerFunc).Params(
+	gen.formatHandlerFunc(group, HandlerFunc)
+}
+
+// formatHandlerFunc generates a function that calls the current handler method. Routed functions also take the path
+// parameters matched by the router.
+func (gen *ServiceGenerator) formatHandlerFunc(group *jen.Group, funcName string) {
+	params := []jen.Code{
 		jen.Id(VariableContext).Qual("context", "Context"),
-		jen.Id(VariableRequest).Qual("github.com/aws/aws-lambda-go/events", "APIGatewayProxyRequest"),
-	).Parens(
+		jen.Id(VariableRequest).Add(gen.target.RequestType()),
+	}
+
+	if gen.routed {
+		params = append(params, jen.Id(VariableRouteParams).Map(jen.String()).String())
+	}
+
+	group.Func().Id(funcName).Params(params...).Parens(
 		jen.List(
-			jen.Qual("github.com/aws/aws-lambda-go/events", "APIGatewayProxyResponse"),
+			gen.target.ResponseType(),
 			jen.Error(),
 		),
 	).BlockFunc(func(group *jen.Group) {
 
 		group.Var().Err().Error()
 
-		// TODO generate the code to make our config variable
-		configVar := "config"
-		gen.formatRequestConfig(group, configVar)
+		// identifies this request in error bodies
+		group.Id(VariableRequestID).Op(":=").Add(gen.target.RequestID())
+
+		// build the request config, if the handler takes one
+		handlerArgs := []jen.Code{jen.Id(VariableContext)}
+		if gen.method.Config.HasConfig() {
+			configVar := "config"
+			gen.formatRequestConfig(group, configVar)
+			handlerArgs = append(handlerArgs, jen.Id(configVar))
+		}
 
 		// call the function
 		responseVar := "response"
-		group.List(jen.Id(responseVar), jen.Err()).Op(":=").Id(VariableHandler).Dot(gen.method.HandlerMethodName).Call(
-			jen.Id(VariableContext),
-			jen.Id(configVar),
-		)
+		handlerCall := jen.Id(gen.handlerVar).Dot(gen.method.HandlerMethodName).Call(handlerArgs...)
+
+		if gen.method.Response.Kind == model.ResponseKindNone {
+			group.Err().Op("=").Add(handlerCall)
+		} else if gen.method.Response.Kind == model.ResponseKindJSON && !statusHasBody(gen.method.SuccessStatus()) {
+			// the value is never sent
+			group.List(jen.Id("_"), jen.Err()).Op("=").Add(handlerCall)
+		} else {
+			group.List(jen.Id(responseVar), jen.Err()).Op(":=").Add(handlerCall)
+		}
 		CheckError(group, func(ifGroup *jen.Group) {
-			GenerateAPIError(ifGroup, 500, "error while processing handler")
+			GenerateHandlerError(ifGroup)
+		})
+
+		gen.formatResponse(group, responseVar)
+	})
+}
+
+// formatResponse encodes the value returned by the handler and returns it
+func (gen *ServiceGenerator) formatResponse(group *jen.Group, responseVar string) {
+	encodedResponseVar := "responseBody"
+	successStatus := gen.method.SuccessStatus()
+	response := gen.method.Response
+
+	switch response.Kind {
+	case model.ResponseKindWrapped:
+		// a nil wrapper is the zero response
+		if response.Pointer {
+			wrappedVar := "wrappedResponse"
+			group.Var().Id(wrappedVar).Add(TypeCode(response.Type.(*types.Pointer).Elem()))
+			group.If(jen.Id(responseVar).Op("!=").Nil()).Block(
+				jen.Id(wrappedVar).Op("=").Op("*").Id(responseVar),
+			)
+			responseVar = wrappedVar
+		}
+
+		// the wrapper can override the status, and some statuses can't have a body or content type
+		responseStatusVar := "responseStatus"
+		contentTypeVar := "responseContentType"
+		group.Id(responseStatusVar).Op(":=").Id(responseVar).Dot("StatusCodeOr").Call(jen.Lit(successStatus))
+		group.Var().Id(encodedResponseVar).String()
+		if response.Encoding != model.BodyEncodingFile {
+			group.Var().Id(contentTypeVar).String()
+		}
+		group.If(jen.Qual("github.com/softwaresale/lambdagen/pkg", "StatusHasBody").Call(jen.Id(responseStatusVar))).BlockFunc(func(group *jen.Group) {
+			formatEncodeBody(group, response.Encoding, jen.Id(responseVar).Dot("Body"), encodedResponseVar)
+			if response.Encoding != model.BodyEncodingFile {
+				group.Id(contentTypeVar).Op("=").Add(defaultContentType(response.Encoding))
+			}
 		})
 
+		parts := responseParts{
+			Status:      jen.Id(responseStatusVar),
+			Headers:     jen.Id(responseVar).Dot("Headers"),
+			Cookies:     jen.Id(responseVar).Dot("Cookies"),
+			ContentType: jen.Id(contentTypeVar),
+			Body:        jen.Id(encodedResponseVar),
+			Base64:      response.Encoding.IsBinary(),
+		}
+
+		// files set their own content type
+		if response.Encoding == model.BodyEncodingFile {
+			parts.Headers = jen.Id(responseVar).Dot("Body").Dot("Header").Call(jen.Id(responseVar).Dot("Headers"))
+			parts.ContentType = nil
+		}
+
+		group.Return(jen.List(gen.target.Response(parts), jen.Nil()))
+
+	case model.ResponseKindNone:
+		group.Return(jen.List(gen.target.Response(responseParts{Status: jen.Lit(successStatus)}), jen.Nil()))
+
+	default:
+		// some statuses can't have a body, so the response value is dropped
+		if !statusHasBody(successStatus) {
+			group.Return(jen.List(gen.target.Response(responseParts{Status: jen.Lit(successStatus)}), jen.Nil()))
+			return
+		}
+
 		// Serialize the body
-		encodedResponseVar := "responseBody"
-		group.List(jen.Id(encodedResponseVar), jen.Err()).Op(":=").Qual("encoding/json", "Marshal").Call(jen.Id(responseVar))
+		group.Var().Id(encodedResponseVar).String()
+		formatEncodeBody(group, response.Encoding, jen.Id(responseVar), encodedResponseVar)
+
+		parts := responseParts{
+			Status:      jen.Lit(successStatus),
+			ContentType: defaultContentType(response.Encoding),
+			Body:        jen.Id(encodedResponseVar),
+			Base64:      response.Encoding.IsBinary(),
+		}
+
+		// files set their own content type
+		if response.Encoding == model.BodyEncodingFile {
+			parts.Headers = jen.Id(responseVar).Dot("Header").Call(jen.Nil())
+			parts.ContentType = nil
+		}
+
+		group.Return(jen.List(gen.target.Response(parts), jen.Nil()))
+	}
+}
+
+// formatEncodeBody encodes a response body into the string variable bodyVar. JSON bodies are marshalled, and binary
+// bodies are base64 encoded.
+func formatEncodeBody(group *jen.Group, encoding model.BodyEncoding, value *jen.Statement, bodyVar string) {
+	bytesVar := "responseBytes"
+
+	switch encoding {
+	case model.BodyEncodingBytes:
+		group.Id(bodyVar).Op("=").Qual("github.com/softwaresale/lambdagen/pkg", "EncodeBinaryBody").Call(value)
+
+	case model.BodyEncodingReader:
+		group.List(jen.Id(bytesVar), jen.Err()).Op(":=").Qual("github.com/softwaresale/lambdagen/pkg", "ReadResponseBody").Call(value)
 		CheckError(group, func(ifGroup *jen.Group) {
-			GenerateAPIError(ifGroup, 500, "failed to serialize body")
+			GenerateAPIError(ifGroup, 500, errorCodeInternal, "failed to read body")
 		})
+		group.Id(bodyVar).Op("=").Qual("github.com/softwaresale/lambdagen/pkg", "EncodeBinaryBody").Call(jen.Id(bytesVar))
 
-		group.Return(
-			jen.List(
-				jen.Qual("github.com/aws/aws-lambda-go/events", "APIGatewayProxyResponse").Values(jen.Dict{
-					jen.Id("StatusCode"): jen.Lit(200),
-					jen.Id("Body"):       jen.String().Parens(jen.Id(encodedResponseVar)),
-				}),
-				jen.Nil(),
-			),
-		)
-	})
+	case model.BodyEncodingFile:
+		group.Id(bodyVar).Op("=").Qual("github.com/softwaresale/lambdagen/pkg", "EncodeBinaryBody").Call(value.Dot("Content"))
+
+	default:
+		group.List(jen.Id(bytesVar), jen.Err()).Op(":=").Qual("encoding/json", "Marshal").Call(value)
+		CheckError(group, func(ifGroup *jen.Group) {
+			GenerateAPIError(ifGroup, 500, errorCodeInternal, "failed to serialize body")
+		})
+		group.Id(bodyVar).Op("=").String().Parens(jen.Id(bytesVar))
+	}
+}
+
+// defaultContentType gets the content type of bodies with the given encoding, unless the handler sets its own
+func defaultContentType(encoding model.BodyEncoding) jen.Code {
+	if encoding.IsBinary() {
+		return jen.Qual("github.com/softwaresale/lambdagen/pkg", "ContentTypeOctetStream")
+	}
+
+	return jen.Qual("github.com/softwaresale/lambdagen/pkg", "ContentTypeJSON")
 }
 
 func (gen *ServiceGenerator) formatRequestConfig(group *jen.Group, configVar string) {
 	fieldAssignments := make(map[string]string)
+	if len(gen.method.Config.Path) > 0 {
+		// routed handlers get the placeholders matched by the router
+		pathParams := gen.target.PathParameters()
+		if gen.routed {
+			pathParams = jen.Id(VariableRouteParams)
+		}
+
+		group.Id(VariablePath).Op(":=").Add(pathParams)
+	}
+
 	for _, pathVar := range gen.method.Config.Path {
 		gen.formatPathVariable(group, pathVar)
-		fieldAssignments[pathVar.FieldName] = pathVar.Name
+		fieldAssignments[pathVar.FieldName] = variableIdent(pathVar)
+	}
+
+	if len(gen.method.Config.Query) > 0 {
+		group.Id(VariableQuery).Op(":=").Add(gen.target.Query())
+
+		for _, queryVar := range gen.method.Config.Query {
+			gen.formatQueryVariable(group, queryVar)
+			fieldAssignments[queryVar.FieldName] = variableIdent(queryVar)
+		}
+	}
+
+	hasBody := len(gen.method.Config.Body.Name) > 0
+	needsMediaType := (hasBody && bodyNeedsMediaType(gen.method.Config.Body.Type)) || gen.method.Config.IsMultipart()
+	if len(gen.method.Config.Headers) > 0 || needsMediaType {
+		// merge the headers up front so that lookups are case-insensitive
+		group.Id(VariableHeaders).Op(":=").Add(gen.target.Headers())
+	}
+
+	if len(gen.method.Config.Headers) > 0 {
+		for _, headerVar := range gen.method.Config.Headers {
+			gen.formatHeaderVariable(group, headerVar)
+			fieldAssignments[headerVar.FieldName] = variableIdent(headerVar)
+		}
 	}
 
-	for _, queryVar := range gen.method.Config.Query {
-		gen.formatQueryVariable(group, queryVar)
-		fieldAssignments[queryVar.FieldName] = queryVar.Name
+	if len(gen.method.Config.Claims) > 0 {
+		gen.target.Claims(group, VariableClaims)
+
+		for _, claimVar := range gen.method.Config.Claims {
+			rawVariable := fmt.Sprintf("%sRaw", variableIdent(claimVar))
+			group.List(jen.Id(rawVariable), jen.Id("ok")).Op(":=").Id(VariableClaims).Index(jen.Lit(claimVar.Name))
+			formatLookupConversion(group, claimVar, rawVariable, fmt.Sprintf("claim '%s' not found", claimVar.Name))
+			fieldAssignments[claimVar.FieldName] = variableIdent(claimVar)
+		}
+	}
+
+	if gen.method.Config.IsMultipart() {
+		gen.formatMultipartForm(group)
+
+		for _, formVar := range gen.method.Config.Form {
+			formatValuesVariable(group, formVar, jen.Id(VariableForm).Dot("Values"), fmt.Sprintf("form field '%s' not found", formVar.Name))
+			fieldAssignments[formVar.FieldName] = variableIdent(formVar)
+		}
+
+		for _, fileVar := range gen.method.Config.Files {
+			formatFileVariable(group, fileVar)
+			fieldAssignments[fileVar.FieldName] = variableIdent(fileVar)
+		}
 	}
 
 	bodyVar := ""
-	if len(gen.method.Config.Body.Name) > 0 {
+	if hasBody {
 		bodyVar = "body"
 		gen.formatBody(group, bodyVar)
 		fieldAssignments[gen.method.Config.Body.FieldName] = bodyVar
@@ -146,51 +483,149 @@ func (gen *ServiceGenerator) formatRequestConfig(group *jen.Group, configVar str
 
 func (gen *ServiceGenerator) formatPathVariable(group *jen.Group, pathVar model.VariableDefinition) {
 	// load the
-	rawVariable := fmt.Sprintf("%sRaw", pathVar.Name)
-	group.List(jen.Id(rawVariable), jen.Id("ok")).Op(":=").Id(VariableRequest).Dot("PathParameters").Index(jen.Id(pathVar.Name))
-	group.If(jen.Op("!").Id("ok")).BlockFunc(func(group *jen.Group) {
-		GenerateAPIError(group, 400, fmt.Sprintf("path variable '%s' not found", pathVar.Name))
-	})
+	rawVariable := fmt.Sprintf("%sRaw", variableIdent(pathVar))
+	group.List(jen.Id(rawVariable), jen.Id("ok")).Op(":=").Id(VariablePath).Index(jen.Lit(pathVar.Name))
 
 	// generate conversion code
-	ConversionCode(group, pathVar.Type, rawVariable, pathVar.Name)
+	formatLookupConversion(group, pathVar, rawVariable, fmt.Sprintf("path variable '%s' not found", pathVar.Name))
 }
 
-func (gen *ServiceGenerator) formatQueryVariable(group *jen.Group, pathVar model.VariableDefinition) {
+func (gen *ServiceGenerator) formatQueryVariable(group *jen.Group, queryVar model.VariableDefinition) {
+	formatValuesVariable(group, queryVar, jen.Id(VariableQuery), fmt.Sprintf("query variable '%s' not found", queryVar.Name))
+}
+
+// formatValuesVariable binds a variable from a url.Values, like the query or the value fields of a multipart form
+func formatValuesVariable(group *jen.Group, variable model.VariableDefinition, values *jen.Statement, missingMessage string) {
+	rawVariable := fmt.Sprintf("%sRaw", variableIdent(variable))
+
+	// slices take every value of a repeated key. A missing key is just an empty slice
+	if sliceTp, ok := valuesSliceType(variable, variable.Type); ok {
+		formatSliceVariable(group, variable, sliceTp, values.Clone().Index(jen.Lit(variable.Name)))
+		return
+	}
+
 	// load the
-	rawVariable := fmt.Sprintf("%sRaw", pathVar.Name)
-	group.List(jen.Id(rawVariable), jen.Id("ok")).Op(":=").Id(VariableRequest).Dot("QueryParams").Index(jen.Id(pathVar.Name))
+	group.List(jen.Id(rawVariable), jen.Id("ok")).Op(":=").Qual("github.com/softwaresale/lambdagen/pkg", "LookupQuery").Call(values.Clone(), jen.Lit(variable.Name))
+
+	// generate conversion code
+	formatLookupConversion(group, variable, rawVariable, missingMessage)
+}
+
+// formatSliceVariable binds a slice variable from every raw value of its key
+func formatSliceVariable(group *jen.Group, variable model.VariableDefinition, sliceTp *types.Slice, rawValues *jen.Statement) {
+	rawVariable := fmt.Sprintf("%sRaw", variableIdent(variable))
+	if variable.HasOption(model.TagOptionCSV) {
+		rawValues = jen.Qual("github.com/softwaresale/lambdagen/pkg", "SplitValues").Call(rawValues)
+	}
+
+	group.Id(rawVariable).Op(":=").Add(rawValues)
+	if defaultValue, ok := variable.Options[model.TagOptionDefault]; ok {
+		group.If(jen.Len(jen.Id(rawVariable)).Op("==").Lit(0)).Block(
+			jen.Id(rawVariable).Op("=").Index().String().Values(jen.Lit(defaultValue)),
+		)
+	}
+
+	SliceConversionCode(group, variable, sliceTp, rawVariable, variableIdent(variable))
+}
+
+func (gen *ServiceGenerator) formatHeaderVariable(group *jen.Group, headerVar model.VariableDefinition) {
+	// headers are merged into a canonical http.Header, so these lookups are case-insensitive
+	if sliceTp, ok := valuesSliceType(headerVar, headerVar.Type); ok {
+		formatSliceVariable(group, headerVar, sliceTp, jen.Id(VariableHeaders).Dot("Values").Call(jen.Lit(headerVar.Name)))
+		return
+	}
+
+	rawVariable := fmt.Sprintf("%sRaw", variableIdent(headerVar))
+	group.List(jen.Id(rawVariable), jen.Id("ok")).Op(":=").Qual("github.com/softwaresale/lambdagen/pkg", "LookupHeader").Call(jen.Id(VariableHeaders), jen.Lit(headerVar.Name))
+
+	// generate conversion code
+	formatLookupConversion(group, headerVar, rawVariable, fmt.Sprintf("header '%s' not found", headerVar.Name))
+}
+
+// formatLookupConversion converts a looked up variable, assuming that `ok` tells if the variable was present. Missing
+// variables take their default value if one is provided. Otherwise, pointer variables are optional and left nil, and
+// all other variables are required.
+func formatLookupConversion(group *jen.Group, variable model.VariableDefinition, rawVariable, missingMessage string) {
+	convertedVariable := variableIdent(variable)
+
+	if defaultValue, ok := variable.Options[model.TagOptionDefault]; ok {
+		group.If(jen.Op("!").Id("ok")).Block(
+			jen.Id(rawVariable).Op("=").Lit(defaultValue),
+		)
+
+		ConversionCode(group, variable, variable.Type, rawVariable, convertedVariable)
+		return
+	}
+
+	if ptrTp, ok := types.Unalias(variable.Type).(*types.Pointer); ok {
+		group.Var().Id(convertedVariable).Add(TypeCode(ptrTp))
+		group.If(jen.Id("ok")).BlockFunc(func(group *jen.Group) {
+			valueVariable := fmt.Sprintf("%sValue", convertedVariable)
+			ConversionCode(group, variable, ptrTp.Elem(), rawVariable, valueVariable)
+			group.Id(convertedVariable).Op("=").Op("&").Id(valueVariable)
+		})
+		return
+	}
+
 	group.If(jen.Op("!").Id("ok")).BlockFunc(func(group *jen.Group) {
-		GenerateAPIError(group, 400, fmt.Sprintf("query variable '%s' not found", pathVar.Name))
+		GenerateFieldError(group, errorCodeMissingParameter, variable.Name, missingMessage)
 	})
 
-	// generate conversion code
-	ConversionCode(group, pathVar.Type, rawVariable, pathVar.Name)
+	ConversionCode(group, variable, variable.Type, rawVariable, convertedVariable)
 }
 
-func (gen *ServiceGenerator) formatBody(group *jen.Group, bodyVar string) {
+// formatRouteHandlerType declares the signature shared by every routed handler function
+func (gen *ServiceGenerator) formatRouteHandlerType(group *jen.Group) {
+	group.Type().Id(RouteHandlerType).Func().Params(
+		jen.Id(VariableContext).Qual("context", "Context"),
+		jen.Id(VariableRequest).Add(gen.target.RequestType()),
+		jen.Id(VariableRouteParams).Map(jen.String()).String(),
+	).Parens(jen.List(gen.target.ResponseType(), jen.Error()))
+}
 
-	var typeFunc func(stmt *jen.Statement)
-	switch bodyType := gen.method.Config.Body.Type.(type) {
-	case *types.Basic:
-		typeFunc = func(stmt *jen.Statement) {
-			stmt.Id(bodyType.Name())
-		}
+// formatRouter generates the lambda handler, which matches the request to a route and calls its handler
+func (gen *ServiceGenerator) formatRouter(group *jen.Group) {
+	routeVar := "route"
 
-	case *types.Named:
-		typeFunc = func(stmt *jen.Statement) {
-			stmt.Qual(bodyType.Obj().Pkg().Path(), bodyType.Obj().Name())
-		}
+	group.Func().Id(HandlerFunc).Params(
+		jen.Id(VariableContext).Qual("context", "Context"),
+		jen.Id(VariableRequest).Add(gen.target.RequestType()),
+	).Parens(
+		jen.List(gen.target.ResponseType(), jen.Error()),
+	).BlockFunc(func(group *jen.Group) {
+		group.List(jen.Id(routeVar), jen.Id(VariableRouteParams), jen.Err()).Op(":=").Qual("github.com/softwaresale/lambdagen/pkg", "MatchRoute").Call(
+			jen.Id(VariableRoutes),
+			gen.target.Method(),
+			gen.target.RoutePath(),
+		)
+		CheckError(group, func(ifGroup *jen.Group) {
+			// unmatched requests respond with 404 or 405
+			ifGroup.Id(VariableRequestID).Op(":=").Add(gen.target.RequestID())
+			GenerateHandlerError(ifGroup)
+		})
 
-	default:
-		panic("invalid body type")
+		group.Return(jen.Id(routeVar).Dot("Handler").Call(jen.Id(VariableContext), jen.Id(VariableRequest), jen.Id(VariableRouteParams)))
+	})
+}
+
+// routeHandlerFuncName gets the name of the routed function that calls a handler method. Qualified names include the
+// service, so that handlers of different services don't collide.
+func routeHandlerFuncName(definition *model.ServiceDefinition, method model.HandlerDefinition, qualified bool) string {
+	if qualified {
+		return "handle" + serviceName(definition) + method.HandlerMethodName
+	}
+
+	return "handle" + method.HandlerMethodName
+}
+
+// serviceName gets the name of a service's type
+func serviceName(definition *model.ServiceDefinition) string {
+	namedTp, ok := definition.Type.(*types.Named)
+	if !ok {
+		panic("handler type must be a named type")
 	}
 
-	unmarshalVar := "bodyBytes"
-	group.Id(unmarshalVar).Op(":=").Index().Byte().Parens(jen.Id(VariableRequest).Dot("Body"))
-	group.Var().Id(bodyVar).Do(typeFunc)
-	group.Err().Op("=").Qual("encoding/json", "Unmarshal").Call(jen.Id(unmarshalVar), jen.Op("&").Id(bodyVar))
-	GenerateAPIError(group, 500, fmt.Sprintf("failed to unmarshal body"))
+	return namedTp.Obj().Name()
 }
 
 func (gen *ServiceGenerator) formatMainFunc(group *jen.Group) {
@@ -0,0 +1,139 @@
+package codegen_test
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/softwaresale/lambdagen/internal/diagnostics"
+	"github.com/softwaresale/lambdagen/internal/model"
+	"github.com/softwaresale/lambdagen/internal/output"
+	"github.com/softwaresale/lambdagen/internal/parsing"
+)
+
+// vetModule is the go.mod of the module that the services under testdata/vet are generated in. The AWS modules are
+// only needed by generated code, so they are kept out of lambdagen's own go.mod.
+const vetModule = `module example.com/vet
+
+go 1.23.0
+
+require (
+	github.com/aws/aws-lambda-go v1.47.0
+	github.com/aws/aws-sdk-go-v2 v1.41.1
+	github.com/aws/aws-sdk-go-v2/config v1.32.9
+	github.com/softwaresale/lambdagen v0.0.0
+)
+
+replace github.com/softwaresale/lambdagen => %s
+`
+
+// generateVetModule copies the services under testdata/vet into a new module and generates lambdas for them
+func generateVetModule(t *testing.T) string {
+	t.Helper()
+
+	root, err := filepath.Abs(filepath.Join("..", ".."))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	moduleDir := t.TempDir()
+	if err := os.CopyFS(moduleDir, os.DirFS(filepath.Join("testdata", "vet"))); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(moduleDir, "go.mod"), []byte(fmt.Sprintf(vetModule, root)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	diags := diagnostics.NewCollector()
+	services, err := parsing.ParseServices(moduleDir, "./...", diags)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diags.HasErrors() {
+		var report strings.Builder
+		_ = diags.Print(&report, false)
+		t.Fatalf("testdata/vet has diagnostics:\n%s", report.String())
+	}
+
+	manager := output.NewManager(moduleDir, "lambda")
+	for _, service := range services {
+		if len(service.Target) == 0 {
+			service.Target = model.DefaultTarget
+		}
+
+		if len(service.Packaging) == 0 {
+			service.Packaging = model.DefaultPackaging
+		}
+
+		for _, handler := range service.Handlers {
+			if err := manager.Register(&service, &handler); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		for _, consumer := range service.Consumers {
+			if err := manager.RegisterConsumer(&service, &consumer); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if err := manager.CreateOutputDir(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := manager.Render(diags); err != nil {
+		t.Fatal(err)
+	}
+
+	if diags.HasErrors() {
+		var report strings.Builder
+		_ = diags.Print(&report, false)
+		t.Fatalf("testdata/vet failed to generate:\n%s", report.String())
+	}
+
+	return moduleDir
+}
+
+// goCommand runs the go command in a directory, adding missing requirements to go.mod and go.sum as needed
+func goCommand(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod")
+	return cmd.CombinedOutput()
+}
+
+func TestGeneratedLambdasVet(t *testing.T) {
+	if testing.Short() {
+		t.Skip("vetting generated lambdas builds a module")
+	}
+
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not found")
+	}
+
+	moduleDir := generateVetModule(t)
+
+	// the generated code needs the AWS modules, which may not be available offline
+	if out, err := goCommand(moduleDir, "list", "-deps", "./..."); err != nil {
+		t.Skipf("dependencies of the generated lambdas are unavailable:\n%s", out)
+	}
+
+	lambdas, err := filepath.Glob(filepath.Join(moduleDir, "lambda", "*", "main.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(lambdas) == 0 {
+		t.Fatal("no lambdas were generated")
+	}
+
+	if out, err := goCommand(moduleDir, "vet", "./..."); err != nil {
+		t.Fatalf("generated lambdas don't vet:\n%s", out)
+	}
+}
@@ -0,0 +1,67 @@
+package codegen
+
+import (
+	"fmt"
+	"github.com/dave/jennifer/jen"
+	"go/types"
+)
+
+// TypeCode renders a type as code that can be used in generated declarations
+func TypeCode(tp types.Type) *jen.Statement {
+	switch tp := tp.(type) {
+	case *types.Basic:
+		return jen.Id(tp.Name())
+
+	case *types.Named:
+		obj := tp.Obj()
+		var stmt *jen.Statement
+		if obj.Pkg() == nil {
+			// builtin type, i.e. error
+			stmt = jen.Id(obj.Name())
+		} else {
+			stmt = jen.Qual(obj.Pkg().Path(), obj.Name())
+		}
+
+		typeArgs := tp.TypeArgs()
+		if typeArgs.Len() > 0 {
+			stmt = stmt.TypesFunc(func(group *jen.Group) {
+				for i := range typeArgs.Len() {
+					group.Add(TypeCode(typeArgs.At(i)))
+				}
+			})
+		}
+
+		return stmt
+
+	case *types.Alias:
+		obj := tp.Obj()
+		if obj.Pkg() == nil {
+			// builtin alias, i.e. any
+			return jen.Id(obj.Name())
+		}
+
+		return jen.Qual(obj.Pkg().Path(), obj.Name())
+
+	case *types.Interface:
+		if !tp.Empty() {
+			panic(fmt.Sprintf("unsupported interface type: %s", tp.String()))
+		}
+
+		return jen.Interface()
+
+	case *types.Pointer:
+		return jen.Op("*").Add(TypeCode(tp.Elem()))
+
+	case *types.Slice:
+		return jen.Index().Add(TypeCode(tp.Elem()))
+
+	case *types.Array:
+		return jen.Index(jen.Lit(int(tp.Len()))).Add(TypeCode(tp.Elem()))
+
+	case *types.Map:
+		return jen.Map(TypeCode(tp.Key())).Add(TypeCode(tp.Elem()))
+
+	default:
+		panic(fmt.Sprintf("unsupported type: %s", tp.String()))
+	}
+}
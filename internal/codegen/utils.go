@@ -1,21 +1,32 @@
 package codegen
 
-import "github.com/dave/jennifer/jen"
+import (
+	"fmt"
+	"github.com/dave/jennifer/jen"
+	"github.com/iancoleman/strcase"
+	"github.com/softwaresale/lambdagen/internal/model"
+)
 
 type FailStrategyFunc func(*jen.Group)
 
-func checkError(failStrategy FailStrategyFunc) *jen.Statement {
-	return jen.If(jen.Err().Op("==").Nil()).BlockFunc(failStrategy)
+func buildCheckError(ctx *jen.Group, failStrategy FailStrategyFunc) {
+	ctx.If(jen.Err().Op("!=").Nil()).BlockFunc(failStrategy)
 }
 
-func buildCheckError(ctx *jen.Group, failStrategy FailStrategyFunc) {
-	ctx.If(jen.Err().Op("==").Nil()).BlockFunc(failStrategy)
+// failStrategyInvalidVariable responds with a 400 naming the request variable that could not be converted
+func failStrategyInvalidVariable(variable model.VariableDefinition) FailStrategyFunc {
+	return func(ctx *jen.Group) {
+		GenerateFieldError(ctx, errorCodeInvalidParameter, variable.Name, fmt.Sprintf("invalid value for '%s'", variable.Name))
+	}
 }
 
-func failStrategyPanic(ctx *jen.Group) {
-	ctx.Panic(jen.Err())
+// variableIdent gets the identifier used for a request variable in generated code. Request variable names can be
+// arbitrary strings (e.g. header names), so the identifier is derived from the config field instead.
+func variableIdent(variable model.VariableDefinition) string {
+	return strcase.ToLowerCamel(variable.FieldName) + "Param"
 }
 
-func checkErrorPanic() *jen.Statement {
-	return checkError(failStrategyPanic)
+// statusHasBody mirrors pkg.StatusHasBody for statuses known at generation time
+func statusHasBody(status int) bool {
+	return status != 204 && status != 304 && status >= 200
 }
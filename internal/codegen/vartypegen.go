@@ -3,38 +3,210 @@ package codegen
 import (
 	"fmt"
 	"github.com/dave/jennifer/jen"
+	"github.com/softwaresale/lambdagen/internal/model"
+	"go/token"
 	"go/types"
 )
 
-func ConversionCode(ctx *jen.Group, tp types.Type, rawVariable, convertedVariable string) {
+// textUnmarshalerType is encoding.TextUnmarshaler
+var textUnmarshalerType = types.NewInterfaceType([]*types.Func{
+	types.NewFunc(token.NoPos, nil, "UnmarshalText", types.NewSignatureType(nil, nil, nil,
+		types.NewTuple(types.NewParam(token.NoPos, nil, "text", types.NewSlice(types.Typ[types.Byte]))),
+		types.NewTuple(types.NewParam(token.NoPos, nil, "", types.Universe.Lookup("error").Type())),
+		false,
+	)),
+}, nil).Complete()
 
-	switch tp := tp.(type) {
+// ConversionCode generates code that converts rawVariable into convertedVariable of type tp. The variable definition
+// supplies any conversion options, like a custom converter.
+func ConversionCode(ctx *jen.Group, variable model.VariableDefinition, tp types.Type, rawVariable, convertedVariable string) {
+
+	// custom converters take precedence over everything else
+	if variable.Converter != nil && types.Identical(variable.ConvertTo, tp) {
+		convert := jen.Qual("github.com/softwaresale/lambdagen/pkg", "RequestVarConvert").Types(TypeCode(tp)).Parens(jen.Qual(variable.Converter.Pkg().Path(), variable.Converter.Name()))
+		ctx.List(jen.Id(convertedVariable), jen.Err()).Op(":=").Add(convert).Call(jen.Id(rawVariable))
+		buildCheckError(ctx, failStrategyInvalidVariable(variable))
+		return
+	}
+
+	// aliases are converted like the type they stand for
+	switch tp := types.Unalias(tp).(type) {
 	case *types.Basic:
 		switch tp.Kind() {
 		case types.Bool:
-			// if a number, needs to be converted using strconv
 			ctx.List(jen.Id(convertedVariable), jen.Err()).Op(":=").Qual("strconv", "ParseBool").Call(jen.Id(rawVariable))
-			// TODO actually throw an API error, but that doesn't exist yet
-			buildCheckError(ctx, failStrategyPanic)
+			buildCheckError(ctx, failStrategyInvalidVariable(variable))
 
 		case types.String:
 			ctx.Id(convertedVariable).Op(":=").Id(rawVariable)
 
-		case types.Int, types.Int8, types.Int16, types.Int32, types.Int64, types.Uint, types.Uint8, types.Uint16, types.Uint32, types.Uint64:
-			// if a number, needs to be converted using strconv
-			ctx.List(jen.Id(convertedVariable), jen.Err()).Op(":=").Qual("strconv", "Atoi").Call(jen.Id(rawVariable))
-			// TODO actually throw an API error, but that doesn't exist yet
-			buildCheckError(ctx, failStrategyPanic)
+		case types.Int, types.Int8, types.Int16, types.Int32, types.Int64:
+			numericConversionCode(ctx, variable, tp, "ParseInt", rawVariable, convertedVariable, jen.Lit(10))
+
+		case types.Uint, types.Uint8, types.Uint16, types.Uint32, types.Uint64:
+			numericConversionCode(ctx, variable, tp, "ParseUint", rawVariable, convertedVariable, jen.Lit(10))
+
+		case types.Float32, types.Float64:
+			numericConversionCode(ctx, variable, tp, "ParseFloat", rawVariable, convertedVariable)
 
 		default:
 			panic(fmt.Sprintf("unsupported basic type: %v", tp.Kind()))
 		}
 
 	case *types.Named:
-		panic("not yet implemented")
+		if builtinConversionCode(ctx, variable, tp, rawVariable, convertedVariable) {
+			return
+		}
+
+		if types.Implements(types.NewPointer(tp), textUnmarshalerType) {
+			ctx.Var().Id(convertedVariable).Add(TypeCode(tp))
+			ctx.Err().Op("=").Id(convertedVariable).Dot("UnmarshalText").Call(jen.Index().Byte().Parens(jen.Id(rawVariable)))
+			buildCheckError(ctx, failStrategyInvalidVariable(variable))
+			return
+		}
+
+		underlying, ok := tp.Underlying().(*types.Basic)
+		if !ok {
+			panic(fmt.Sprintf("%s needs a converter or must implement encoding.TextUnmarshaler", tp.String()))
+		}
+
+		// convert to the underlying type, then to our named type
+		baseVariable := fmt.Sprintf("%sBase", convertedVariable)
+		ConversionCode(ctx, variable, underlying, rawVariable, baseVariable)
+		ctx.Id(convertedVariable).Op(":=").Add(TypeCode(tp)).Parens(jen.Id(baseVariable))
+
+	case *types.Pointer:
+		valueVariable := fmt.Sprintf("%sValue", convertedVariable)
+		ConversionCode(ctx, variable, tp.Elem(), rawVariable, valueVariable)
+		ctx.Id(convertedVariable).Op(":=").Op("&").Id(valueVariable)
 
 	default:
 		panic("unsupported variable type")
 	}
 
 }
+
+// timeLayouts are the layout constants from the time package that can be named in a format option. Layouts with
+// spaces can't be written in a tag directly, so these are the only way to use them.
+var timeLayouts = map[string]bool{
+	"Layout": true, "ANSIC": true, "UnixDate": true, "RubyDate": true, "RFC822": true, "RFC822Z": true, "RFC850": true,
+	"RFC1123": true, "RFC1123Z": true, "RFC3339": true, "RFC3339Nano": true, "Kitchen": true, "Stamp": true,
+	"StampMilli": true, "StampMicro": true, "StampNano": true, "DateTime": true, "DateOnly": true, "TimeOnly": true,
+}
+
+// uuidParsers maps common UUID types to the function that parses them
+var uuidParsers = map[string]string{
+	"github.com/google/uuid.UUID":    "Parse",
+	"github.com/gofrs/uuid.UUID":     "FromString",
+	"github.com/gofrs/uuid/v5.UUID":  "FromString",
+	"github.com/satori/go.uuid.UUID": "FromString",
+}
+
+// builtinConversionCode generates conversions for well-known named types: time.Time, time.Duration, and common UUID
+// types. Returns false if tp is not one of these types.
+func builtinConversionCode(ctx *jen.Group, variable model.VariableDefinition, tp *types.Named, rawVariable, convertedVariable string) bool {
+	obj := tp.Obj()
+	if obj.Pkg() == nil {
+		return false
+	}
+
+	pkgPath := obj.Pkg().Path()
+	switch qualifiedName := fmt.Sprintf("%s.%s", pkgPath, obj.Name()); qualifiedName {
+	case "time.Time":
+		layout := jen.Qual("time", "RFC3339")
+		if format, ok := variable.Options[model.TagOptionFormat]; ok {
+			if timeLayouts[format] {
+				layout = jen.Qual("time", format)
+			} else {
+				layout = jen.Lit(format)
+			}
+		}
+
+		ctx.List(jen.Id(convertedVariable), jen.Err()).Op(":=").Qual("time", "Parse").Call(layout, jen.Id(rawVariable))
+		buildCheckError(ctx, failStrategyInvalidVariable(variable))
+		return true
+
+	case "time.Duration":
+		ctx.List(jen.Id(convertedVariable), jen.Err()).Op(":=").Qual("time", "ParseDuration").Call(jen.Id(rawVariable))
+		buildCheckError(ctx, failStrategyInvalidVariable(variable))
+		return true
+
+	default:
+		parseFunc, ok := uuidParsers[qualifiedName]
+		if !ok {
+			return false
+		}
+
+		ctx.List(jen.Id(convertedVariable), jen.Err()).Op(":=").Qual(pkgPath, parseFunc).Call(jen.Id(rawVariable))
+		buildCheckError(ctx, failStrategyInvalidVariable(variable))
+		return true
+	}
+}
+
+// numericConversionCode parses a number with the given strconv function at the bit size of tp. strconv always produces
+// the 64-bit type of a kind, so anything narrower gets an explicit conversion. Values that overflow the bit size fail
+// to parse.
+func numericConversionCode(ctx *jen.Group, variable model.VariableDefinition, tp *types.Basic, parseFunc, rawVariable, convertedVariable string, baseArgs ...jen.Code) {
+	var bitSize int
+	switch tp.Kind() {
+	case types.Int8, types.Uint8:
+		bitSize = 8
+	case types.Int16, types.Uint16:
+		bitSize = 16
+	case types.Int32, types.Uint32, types.Float32:
+		bitSize = 32
+	case types.Int64, types.Uint64, types.Float64:
+		bitSize = 64
+	default:
+		// int and uint are platform sized
+		bitSize = 0
+	}
+
+	args := append([]jen.Code{jen.Id(rawVariable)}, baseArgs...)
+	args = append(args, jen.Lit(bitSize))
+
+	switch tp.Kind() {
+	case types.Int64, types.Uint64, types.Float64:
+		ctx.List(jen.Id(convertedVariable), jen.Err()).Op(":=").Qual("strconv", parseFunc).Call(args...)
+		buildCheckError(ctx, failStrategyInvalidVariable(variable))
+
+	default:
+		parsedVariable := fmt.Sprintf("%sParsed", convertedVariable)
+		ctx.List(jen.Id(parsedVariable), jen.Err()).Op(":=").Qual("strconv", parseFunc).Call(args...)
+		buildCheckError(ctx, failStrategyInvalidVariable(variable))
+		ctx.Id(convertedVariable).Op(":=").Id(tp.Name()).Parens(jen.Id(parsedVariable))
+	}
+}
+
+// valuesSliceType gets the slice that a variable of type tp is built as when it takes every value of a key. Named
+// slices are built like their underlying slice, unless a converter or encoding.TextUnmarshaler converts the whole type
+// from a single value.
+func valuesSliceType(variable model.VariableDefinition, tp types.Type) (*types.Slice, bool) {
+	tp = types.Unalias(tp)
+	if variable.Converter != nil && types.Identical(variable.ConvertTo, tp) {
+		return nil, false
+	}
+
+	if named, ok := tp.(*types.Named); ok {
+		if types.Implements(types.NewPointer(named), textUnmarshalerType) {
+			return nil, false
+		}
+
+		tp = named.Underlying()
+	}
+
+	sliceTp, ok := tp.(*types.Slice)
+	return sliceTp, ok
+}
+
+// SliceConversionCode converts a raw []string variable into a slice of the given type, converting each element
+func SliceConversionCode(ctx *jen.Group, variable model.VariableDefinition, tp *types.Slice, rawVariable, convertedVariable string) {
+	ctx.Id(convertedVariable).Op(":=").Make(TypeCode(tp), jen.Lit(0), jen.Len(jen.Id(rawVariable)))
+
+	rawElemVariable := fmt.Sprintf("%sElemRaw", convertedVariable)
+	convertedElemVariable := fmt.Sprintf("%sElem", convertedVariable)
+	ctx.For(jen.List(jen.Id("_"), jen.Id(rawElemVariable)).Op(":=").Range().Id(rawVariable)).BlockFunc(func(loop *jen.Group) {
+		ConversionCode(loop, variable, tp.Elem(), rawElemVariable, convertedElemVariable)
+		loop.Id(convertedVariable).Op("=").Append(jen.Id(convertedVariable), jen.Id(convertedElemVariable))
+	})
+}
@@ -0,0 +1,233 @@
+package diagnostics
+
+import (
+	"errors"
+	"fmt"
+	"go/token"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Severity describes how serious a diagnostic is. Only errors fail generation.
+type Severity int
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+	SeverityInfo
+)
+
+func (severity Severity) String() string {
+	switch severity {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	case SeverityInfo:
+		return "info"
+	default:
+		return "unknown"
+	}
+}
+
+// Code is a stable identifier for a kind of diagnostic. Codes are never reused or renumbered.
+type Code string
+
+const (
+	CodePackageError            Code = "LG001" // the package failed to load or type check
+	CodeInvalidConverter        Code = "LG002" // a lambdagen:converter function has the wrong signature
+	CodeGenerationFailed        Code = "LG003" // code for a lambda couldn't be generated
+	CodeServiceFound            Code = "LG100" // a service was found
+	CodeMissingInitializer      Code = "LG101" // a service has no usable initializer
+	CodeInvalidInitializer      Code = "LG102" // a service_init function has the wrong signature
+	CodeMissingDeclaration      Code = "LG103" // a service method has no declaration in the package
+	CodeInvalidServiceCfg       Code = "LG104" // a service annotation has an invalid config value
+	CodeServiceConflict         Code = "LG105" // services bundled into one lambda conflict with each other
+	CodeInvalidHandlerRoute     Code = "LG201" // a handler annotation has an invalid route
+	CodeInvalidHandlerCfg       Code = "LG202" // a handler config struct is invalid
+	CodeInvalidHandlerSignature Code = "LG203" // a handler method has a signature that can't be called
+	CodePathVariableMismatch    Code = "LG204" // path placeholders and pathvar fields don't line up
+	CodeRouteConflict           Code = "LG205" // a route is mapped by more than one handler
+	CodeInvalidConsumerSource   Code = "LG301" // a consumer annotation has an invalid event source or option
+	CodeInvalidConsumerSig      Code = "LG302" // a consumer method has a signature that can't be called
+)
+
+// Diagnostic is a single problem found while generating lambdas
+type Diagnostic struct {
+	Pos      token.Position
+	Severity Severity
+	Code     Code
+	Message  string
+}
+
+// String formats the diagnostic like a compiler message, i.e. file.go:12:3: error[LG101]: message
+func (diagnostic Diagnostic) String() string {
+	if diagnostic.Pos.IsValid() {
+		return fmt.Sprintf("%s: %s[%s]: %s", diagnostic.Pos, diagnostic.Severity, diagnostic.Code, diagnostic.Message)
+	}
+
+	return fmt.Sprintf("%s[%s]: %s", diagnostic.Severity, diagnostic.Code, diagnostic.Message)
+}
+
+// Collector aggregates diagnostics so that every problem is reported instead of just the first
+type Collector struct {
+	diagnostics []Diagnostic
+}
+
+func NewCollector() *Collector {
+	return &Collector{}
+}
+
+// Report adds a diagnostic at the given position
+func (collector *Collector) Report(pos token.Position, severity Severity, code Code, format string, args ...any) {
+	collector.diagnostics = append(collector.diagnostics, Diagnostic{
+		Pos:      pos,
+		Severity: severity,
+		Code:     code,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// ReportPos reports a diagnostic from a position string like the ones go/packages produces, i.e. file.go:12:3
+func (collector *Collector) ReportPos(pos string, severity Severity, code Code, format string, args ...any) {
+	collector.Report(parsePosition(pos), severity, code, format, args...)
+}
+
+// ReportError reports an error diagnostic. If err is an *Error, then its code and position are used. Otherwise, the
+// fallback code and position are. Joined errors are reported individually.
+func (collector *Collector) ReportError(fset *token.FileSet, fallbackPos token.Pos, fallbackCode Code, err error) {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, joinedErr := range joined.Unwrap() {
+			collector.ReportError(fset, fallbackPos, fallbackCode, joinedErr)
+		}
+
+		return
+	}
+
+	pos, code := fallbackPos, fallbackCode
+
+	var diagErr *Error
+	if errors.As(err, &diagErr) {
+		code = diagErr.Code
+		if diagErr.Pos.IsValid() {
+			pos = diagErr.Pos
+		}
+	}
+
+	collector.Report(fset.Position(pos), SeverityError, code, "%s", err)
+}
+
+// ErrorCount gets the number of error diagnostics reported
+func (collector *Collector) ErrorCount() int {
+	count := 0
+	for _, diagnostic := range collector.diagnostics {
+		if diagnostic.Severity == SeverityError {
+			count++
+		}
+	}
+
+	return count
+}
+
+func (collector *Collector) HasErrors() bool {
+	return collector.ErrorCount() > 0
+}
+
+// Diagnostics gets all reported diagnostics ordered by position
+func (collector *Collector) Diagnostics() []Diagnostic {
+	sorted := make([]Diagnostic, len(collector.diagnostics))
+	copy(sorted, collector.diagnostics)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		left, right := sorted[i].Pos, sorted[j].Pos
+		if left.Filename != right.Filename {
+			return left.Filename < right.Filename
+		}
+
+		if left.Line != right.Line {
+			return left.Line < right.Line
+		}
+
+		return left.Column < right.Column
+	})
+
+	return sorted
+}
+
+// Print writes every diagnostic, followed by a summary if there were errors. Info diagnostics are only written when
+// verbose is set.
+func (collector *Collector) Print(output io.Writer, verbose bool) error {
+	for _, diagnostic := range collector.Diagnostics() {
+		if diagnostic.Severity == SeverityInfo && !verbose {
+			continue
+		}
+
+		_, err := fmt.Fprintln(output, diagnostic.String())
+		if err != nil {
+			return err
+		}
+	}
+
+	if errorCount := collector.ErrorCount(); errorCount > 0 {
+		_, err := fmt.Fprintf(output, "%d error(s) generated\n", errorCount)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parsePosition parses a file:line:col position string. Missing parts are left zero.
+func parsePosition(pos string) token.Position {
+	parts := strings.Split(pos, ":")
+
+	// the filename may itself contain colons, so parse numbers from the end
+	var numbers []int
+	for len(parts) > 1 && len(numbers) < 2 {
+		number, err := strconv.Atoi(parts[len(parts)-1])
+		if err != nil {
+			break
+		}
+
+		numbers = append([]int{number}, numbers...)
+		parts = parts[:len(parts)-1]
+	}
+
+	position := token.Position{Filename: strings.Join(parts, ":")}
+	if len(numbers) > 0 {
+		position.Line = numbers[0]
+	}
+
+	if len(numbers) > 1 {
+		position.Column = numbers[1]
+	}
+
+	return position
+}
+
+// Error is an error that carries its diagnostic code and, optionally, the position it refers to. It lets code that
+// returns errors decide how they are reported.
+type Error struct {
+	Pos  token.Pos
+	Code Code
+	Err  error
+}
+
+func Errorf(pos token.Pos, code Code, format string, args ...any) *Error {
+	return &Error{
+		Pos:  pos,
+		Code: code,
+		Err:  fmt.Errorf(format, args...),
+	}
+}
+
+func (err *Error) Error() string {
+	return err.Err.Error()
+}
+
+func (err *Error) Unwrap() error {
+	return err.Err
+}
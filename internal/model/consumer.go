@@ -0,0 +1,33 @@
+package model
+
+import (
+	"go/token"
+	"go/types"
+)
+
+// EventSource is the queue or stream that invokes a consumer
+type EventSource string
+
+const (
+	EventSourceSQS EventSource = "sqs" // an SQS queue, which sends batches of messages
+)
+
+// MessageEncoding describes how a message body is decoded
+type MessageEncoding int
+
+const (
+	MessageEncodingJSON   MessageEncoding = iota // the body is unmarshalled as JSON
+	MessageEncodingString                        // the body is passed as is
+	MessageEncodingBytes                         // the body is passed as a []byte
+)
+
+// ConsumerDefinition describes a service method that consumes messages from an event source
+type ConsumerDefinition struct {
+	Source             EventSource
+	MessageType        types.Type      // MessageType is the type of the message parameter
+	Encoding           MessageEncoding // Encoding is how message bodies are decoded into MessageType
+	Concurrency        int             // Concurrency is how many messages of a batch are processed at once. FIFO batches are always in order
+	BatchSize          int             // BatchSize is the largest batch the event source sends. 0 means the source default
+	ConsumerMethodName string
+	Pos                token.Pos // Pos is the position of the consumer method's name
+}
@@ -1,7 +1,61 @@
 package model
 
+import "strings"
+
 // LambdaMetadata describes the metadata used by CDK to determine how to specify this lambda
 type LambdaMetadata struct {
-	Method string `json:"method"`
-	Path   string `json:"path"`
+	Method         string           `json:"method,omitempty"`               // Method is the routed method of single-method handlers
+	Methods        []string         `json:"methods,omitempty"`              // Methods are the routed methods. Empty for routed lambdas
+	Path           string           `json:"path,omitempty"`                 // Path is the API Gateway route. Empty for other targets
+	Target         Target           `json:"target,omitempty"`               // Target is the event source the lambda expects. Empty for consumers
+	PayloadVersion string           `json:"payloadFormatVersion,omitempty"` // PayloadVersion is the API Gateway payload format
+	ALBRule        *ALBRule         `json:"albRule,omitempty"`              // ALBRule describes the listener rule for ALB targets
+	FunctionURL    *FunctionURLSpec `json:"functionUrl,omitempty"`          // FunctionURL describes the function URL for function URL targets
+	Routes         []RouteMetadata  `json:"routes,omitempty"`               // Routes are the routes a routed lambda dispatches to its handlers
+	EventSource    *EventSourceSpec `json:"eventSource,omitempty"`          // EventSource describes the event source mapping of consumers
+}
+
+// EventSourceSpec describes the event source mapping that invokes a consumer
+type EventSourceSpec struct {
+	Type                    EventSource `json:"type"`
+	BatchSize               int         `json:"batchSize,omitempty"`     // BatchSize is the largest batch to send. Empty means the source default
+	ReportBatchItemFailures bool        `json:"reportBatchItemFailures"` // ReportBatchItemFailures is set if the consumer reports partial batch failures
+}
+
+// FunctionURLSpec describes the function URL of a lambda
+type FunctionURLSpec struct {
+	AuthType string `json:"authType"` // AuthType is NONE or AWS_IAM
+}
+
+// RouteMetadata describes a route served by a routed lambda
+type RouteMetadata struct {
+	Methods []string `json:"methods"`
+	Path    string   `json:"path"`
+	ALBRule *ALBRule `json:"albRule,omitempty"` // ALBRule describes the listener rule for ALB targets
+}
+
+// ALBRule describes the listener rule conditions and target group attributes that route requests to a lambda
+type ALBRule struct {
+	PathPatterns      []string `json:"pathPatterns"`                 // PathPatterns are path-pattern conditions, with placeholders as wildcards
+	HTTPMethods       []string `json:"httpRequestMethods,omitempty"` // HTTPMethods are http-request-method conditions. Empty matches every method
+	MultiValueHeaders bool     `json:"multiValueHeaders"`            // MultiValueHeaders is the lambda.multi_value_headers.enabled attribute
+}
+
+// ALBPathPattern converts a path template into an ALB path pattern, i.e. /orders/{orderId} becomes /orders/*
+func ALBPathPattern(template PathTemplate) string {
+	var pattern strings.Builder
+	for _, segment := range template.Segments {
+		pattern.WriteString("/")
+		if segment.Variable {
+			pattern.WriteString("*")
+		} else {
+			pattern.WriteString(segment.Value)
+		}
+	}
+
+	if pattern.Len() == 0 {
+		return "/"
+	}
+
+	return pattern.String()
 }
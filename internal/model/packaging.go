@@ -0,0 +1,30 @@
+package model
+
+import "fmt"
+
+// Packaging decides how handlers are grouped into lambdas
+type Packaging string
+
+const (
+	PackagingHandler  Packaging = "handler"  // every handler gets its own lambda
+	PackagingService  Packaging = "service"  // every service gets one lambda with a router for its handlers
+	PackagingMonolith Packaging = "monolith" // every service is served by a single lambda with one router
+)
+
+// DefaultPackaging is used for services that don't select a packaging
+const DefaultPackaging = PackagingHandler
+
+// ParsePackaging checks that a packaging name is supported
+func ParsePackaging(packaging string) (Packaging, error) {
+	switch parsed := Packaging(packaging); parsed {
+	case PackagingHandler, PackagingService, PackagingMonolith:
+		return parsed, nil
+	default:
+		return "", fmt.Errorf("packaging must be '%s', '%s', or '%s', but got '%s'", PackagingHandler, PackagingService, PackagingMonolith, packaging)
+	}
+}
+
+// Routed checks if lambdas with this packaging serve more than one handler, and so need a generated router
+func (packaging Packaging) Routed() bool {
+	return packaging == PackagingService || packaging == PackagingMonolith
+}
@@ -0,0 +1,98 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PathSegment is a single '/' separated part of a handler path
+type PathSegment struct {
+	Value    string // Value is the literal text of the segment, or the placeholder name for variables
+	Variable bool   // Variable is set for {placeholder} segments
+	Greedy   bool   // Greedy is set for {placeholder+} segments, which match the rest of the path
+}
+
+// PathTemplate is a parsed handler path, i.e. /orders/{orderId}
+type PathTemplate struct {
+	Segments []PathSegment
+}
+
+// ParsePathTemplate splits a handler path into segments. Placeholders must take up an entire segment, be unique, and
+// greedy placeholders can only be the last segment.
+func ParsePathTemplate(path string) (PathTemplate, error) {
+	if !strings.HasPrefix(path, "/") {
+		return PathTemplate{}, fmt.Errorf("path '%s' must start with '/'", path)
+	}
+
+	var template PathTemplate
+	seen := make(map[string]bool)
+
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	for idx, part := range parts {
+		if len(part) == 0 {
+			if len(parts) == 1 {
+				// root path
+				break
+			}
+
+			return PathTemplate{}, fmt.Errorf("path '%s' has an empty segment", path)
+		}
+
+		if !strings.ContainsAny(part, "{}") {
+			template.Segments = append(template.Segments, PathSegment{Value: part})
+			continue
+		}
+
+		if !strings.HasPrefix(part, "{") || !strings.HasSuffix(part, "}") || strings.Count(part, "{") != 1 || strings.Count(part, "}") != 1 {
+			return PathTemplate{}, fmt.Errorf("segment '%s' of path '%s' must be entirely a {placeholder}", part, path)
+		}
+
+		name := part[1 : len(part)-1]
+		greedy := strings.HasSuffix(name, "+")
+		name = strings.TrimSuffix(name, "+")
+
+		if len(name) == 0 {
+			return PathTemplate{}, fmt.Errorf("path '%s' has an empty placeholder", path)
+		}
+
+		if greedy && idx != len(parts)-1 {
+			return PathTemplate{}, fmt.Errorf("greedy placeholder '%s' must be the last segment of path '%s'", name, path)
+		}
+
+		if seen[name] {
+			return PathTemplate{}, fmt.Errorf("placeholder '%s' appears more than once in path '%s'", name, path)
+		}
+
+		seen[name] = true
+		template.Segments = append(template.Segments, PathSegment{
+			Value:    name,
+			Variable: true,
+			Greedy:   greedy,
+		})
+	}
+
+	return template, nil
+}
+
+// Variables gets the names of all placeholders in this template
+func (template PathTemplate) Variables() []string {
+	var variables []string
+	for _, segment := range template.Segments {
+		if segment.Variable {
+			variables = append(variables, segment.Value)
+		}
+	}
+
+	return variables
+}
+
+// HasVariable checks if this template has a placeholder with the given name
+func (template PathTemplate) HasVariable(name string) bool {
+	for _, variable := range template.Variables() {
+		if variable == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePathTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		want    []PathSegment
+		wantErr bool
+	}{
+		{name: "root", path: "/", want: nil},
+		{name: "literal", path: "/orders", want: []PathSegment{{Value: "orders"}}},
+		{name: "trailing slash", path: "/orders/", want: []PathSegment{{Value: "orders"}}},
+		{
+			name: "placeholder",
+			path: "/orders/{orderId}",
+			want: []PathSegment{{Value: "orders"}, {Value: "orderId", Variable: true}},
+		},
+		{
+			name: "greedy placeholder",
+			path: "/files/{path+}",
+			want: []PathSegment{{Value: "files"}, {Value: "path", Variable: true, Greedy: true}},
+		},
+		{name: "missing leading slash", path: "orders", wantErr: true},
+		{name: "empty segment", path: "/orders//items", wantErr: true},
+		{name: "partial placeholder", path: "/orders/id-{orderId}", wantErr: true},
+		{name: "unbalanced braces", path: "/orders/{orderId", wantErr: true},
+		{name: "nested braces", path: "/orders/{{orderId}}", wantErr: true},
+		{name: "empty placeholder", path: "/orders/{}", wantErr: true},
+		{name: "empty greedy placeholder", path: "/orders/{+}", wantErr: true},
+		{name: "greedy placeholder not last", path: "/files/{path+}/meta", wantErr: true},
+		{name: "duplicate placeholder", path: "/orders/{id}/items/{id}", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParsePathTemplate(test.path)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("ParsePathTemplate(%q) = %v, want an error", test.path, got)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ParsePathTemplate(%q) unexpected error: %v", test.path, err)
+			}
+
+			if !reflect.DeepEqual(got.Segments, test.want) {
+				t.Errorf("ParsePathTemplate(%q) = %v, want %v", test.path, got.Segments, test.want)
+			}
+		})
+	}
+}
@@ -1,7 +1,10 @@
 package model
 
 import (
+	"fmt"
+	"math"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -18,11 +21,35 @@ const (
 	ObjectRolePathVar     = "pathvar"      // this field is a path variable
 	ObjectRoleQueryParam  = "queryvar"     // this field is a query variable
 	ObjectRoleBody        = "body"         // this field is the request body
+	ObjectRoleHeader      = "header"       // this field is a request header, slice fields get every value of the header
+	ObjectRoleConverter   = "converter"    // function that converts raw request variables into a custom type
+	ObjectRoleFile        = "file"         // this field is a file uploaded in a multipart/form-data body
+	ObjectRoleFormVar     = "formvar"      // this field is a value field of a multipart/form-data body
+	ObjectRoleClaim       = "claim"        // this field is a claim from the request authorizer, i.e. a JWT claim
+	ObjectRoleConsumer    = "consumer"     // this function belongs to a service, consumes messages from a queue
 )
 
+const (
+	TagOptionCSV       = "csv"       // slice variables are also split on commas, i.e. ?ids=1,2,3
+	TagOptionDefault   = "default"   // value used when the variable is missing from the request, i.e. default=50. It can't contain commas
+	TagOptionConverter = "converter" // name of the function used to convert this variable, i.e. converter=ParseOrderID
+	TagOptionFormat    = "format"    // layout for time variables, either a time constant or literal, i.e. format=DateOnly
+	TagOptionMaxSize   = "max_size"  // largest accepted file upload, in bytes or with a KB, MB, or GB suffix, i.e. max_size=5MB
+)
+
+// IsValidFieldRoleStr checks if a role can be used in a config field tag
+func IsValidFieldRoleStr(roleStr string) bool {
+	switch roleStr {
+	case ObjectRolePathVar, ObjectRoleQueryParam, ObjectRoleBody, ObjectRoleHeader, ObjectRoleFile, ObjectRoleFormVar, ObjectRoleClaim:
+		return true
+	default:
+		return false
+	}
+}
+
 func IsValidRoleStr(roleStr string) bool {
 	switch roleStr {
-	case ObjectRoleServiceTp, ObjectRoleServiceInit, ObjectRoleHandlerTp, ObjectRolePathVar, ObjectRoleQueryParam, ObjectRoleBody:
+	case ObjectRoleServiceTp, ObjectRoleServiceInit, ObjectRoleHandlerTp, ObjectRolePathVar, ObjectRoleQueryParam, ObjectRoleBody, ObjectRoleHeader, ObjectRoleConverter, ObjectRoleFile, ObjectRoleFormVar, ObjectRoleClaim, ObjectRoleConsumer:
 		return true
 	default:
 		return false
@@ -51,6 +78,30 @@ func (role ObjectRole) GetServiceConfig() map[string]string {
 	return config
 }
 
+// GetTagOptions parses the options that follow the variable name in a field tag, i.e. lambdagen:"queryvar,ids,csv".
+// Options are either flags or key-value pairs. Flags are mapped to an empty value. Options are separated by commas, so
+// values can't contain commas.
+func (role ObjectRole) GetTagOptions() map[string]string {
+	options := make(map[string]string)
+
+	args := strings.Split(role.Args, ",")
+	if len(args) < 2 {
+		return options
+	}
+
+	for _, arg := range args[1:] {
+		key, value, _ := strings.Cut(arg, "=")
+		key = strings.TrimSpace(key)
+		if len(key) == 0 {
+			continue
+		}
+
+		options[key] = strings.TrimSpace(value)
+	}
+
+	return options
+}
+
 // ParseObjectRoleDocstring parses a docstring looking for a valid object role. It finds the first role present. If
 // a role is present, then return the role and true. Otherwise, return empty and false.
 func ParseObjectRoleDocstring(docsOrTags string) (ObjectRole, bool) {
@@ -86,10 +137,10 @@ func ParseObjectRoleDocstring(docsOrTags string) (ObjectRole, bool) {
 		}
 	}
 
-	// if we found args, pull them
+	// if we found args, pull them. separatorIdx is relative to the role
 	argStr := ""
 	if separatorIdx != -1 {
-		argStr = strings.Join(fields[separatorIdx+1:], " ")
+		argStr = strings.Join(fields[roleIdx+separatorIdx+1:], " ")
 	}
 
 	return ObjectRole{
@@ -113,8 +164,8 @@ func ParseObjectRoleTag(tag string) (ObjectRole, bool) {
 			return ObjectRole{}, false
 		}
 
-		// param 1 should be a valid role
-		if !IsValidRoleStr(params[0]) {
+		// param 1 should be a valid field role
+		if !IsValidFieldRoleStr(params[0]) {
 			return ObjectRole{}, false
 		}
 
@@ -126,3 +177,38 @@ func ParseObjectRoleTag(tag string) (ObjectRole, bool) {
 
 	return ObjectRole{}, false
 }
+
+// byteSizeUnits are the suffixes accepted by ParseByteSize
+var byteSizeUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+// ParseByteSize parses a size option like 512, 64KB, or 5MB into bytes. Units are powers of 1024.
+func ParseByteSize(size string) (int64, error) {
+	multiplier := int64(1)
+	number := strings.ToUpper(strings.TrimSpace(size))
+	for _, unit := range byteSizeUnits {
+		if strings.HasSuffix(number, unit.suffix) {
+			number = strings.TrimSuffix(number, unit.suffix)
+			multiplier = unit.multiplier
+			break
+		}
+	}
+
+	value, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || value <= 0 {
+		return 0, fmt.Errorf("invalid size '%s', expected a positive number of bytes with an optional KB, MB, or GB suffix", size)
+	}
+
+	if value > math.MaxInt64/multiplier {
+		return 0, fmt.Errorf("size '%s' is too large", size)
+	}
+
+	return value * multiplier, nil
+}
@@ -0,0 +1,54 @@
+package model
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		size    string
+		want    int64
+		wantErr bool
+	}{
+		{size: "512", want: 512},
+		{size: "512B", want: 512},
+		{size: "64KB", want: 64 << 10},
+		{size: "5MB", want: 5 << 20},
+		{size: "2GB", want: 2 << 30},
+		{size: "5mb", want: 5 << 20},
+		{size: " 5MB ", want: 5 << 20},
+		{size: "8589934591GB", want: 8589934591 << 30},
+		{size: "9223372036854775807", want: math.MaxInt64},
+		{size: "", wantErr: true},
+		{size: "MB", wantErr: true},
+		{size: "0", wantErr: true},
+		{size: "-5MB", wantErr: true},
+		{size: "1.5MB", wantErr: true},
+		{size: "5TB", wantErr: true},
+		{size: "5 MB", wantErr: true},
+		{size: "9000000000GB", wantErr: true},
+		{size: "9223372036854775807KB", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.size, func(t *testing.T) {
+			got, err := ParseByteSize(test.size)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("ParseByteSize(%q) = %d, want an error", test.size, got)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ParseByteSize(%q) unexpected error: %v", test.size, err)
+			}
+
+			if got != test.want {
+				t.Errorf("ParseByteSize(%q) = %d, want %d", test.size, got, test.want)
+			}
+		})
+	}
+}
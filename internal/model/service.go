@@ -1,35 +1,146 @@
 package model
 
 import (
+	"go/token"
 	"go/types"
 	"golang.org/x/tools/go/packages"
+	"path"
 )
 
 // ServiceDefinition describes an API with a collection of endpoint handlers
 type ServiceDefinition struct {
-	Pkg      *packages.Package   // Pkg is the package that contains this service def. Used for translation stuff
-	Type     types.Type          // Type is the type of the struct that is designated the struct handler
-	Init     types.Object        // Init is the function responsible for initializing this service
-	Handlers []HandlerDefinition // Handlers is the collection of handler methods
-	Config   map[string]string   // Config is service-level configuration variables provided in the header line
+	Pkg       *packages.Package    // Pkg is the package that contains this service def. Used for translation stuff
+	Type      types.Type           // Type is the type of the struct that is designated the struct handler
+	Init      types.Object         // Init is the function responsible for initializing this service
+	Handlers  []HandlerDefinition  // Handlers is the collection of handler methods
+	Consumers []ConsumerDefinition // Consumers is the collection of queue consumer methods
+	Config    map[string]string    // Config is service-level configuration variables provided in the header line
+	Target    Target               // Target is the event source the handlers are generated for. Empty means the default
+	Packaging Packaging            // Packaging is how the handlers are grouped into lambdas. Empty means the default
+}
+
+// LambdaPackaging gets how the service's handlers are grouped into lambdas. Targets without routing always need at
+// least a lambda per service.
+func (service ServiceDefinition) LambdaPackaging() Packaging {
+	if service.Packaging == PackagingHandler && service.Target.Routed() {
+		return PackagingService
+	}
+
+	return service.Packaging
+}
+
+// HandlerPath gets the full path of a handler, including the service base path
+func (service ServiceDefinition) HandlerPath(handler HandlerDefinition) string {
+	if basePath, ok := service.Config["base_path"]; ok {
+		return path.Join(basePath, handler.Path)
+	}
+
+	return handler.Path
+}
+
+// ALBMultiValueHeaders checks if the service's ALB target group has multi-value headers enabled
+func (service ServiceDefinition) ALBMultiValueHeaders() bool {
+	return service.Config["alb_multi_value_headers"] == "true"
+}
+
+// FunctionURLAuthType gets the auth type of the service's function URL, which defaults to AWS_IAM
+func (service ServiceDefinition) FunctionURLAuthType() string {
+	if authType, ok := service.Config["auth_type"]; ok {
+		return authType
+	}
+
+	return "AWS_IAM"
 }
 
 type HandlerDefinition struct {
-	Method            string
+	Methods           []string // Methods are the HTTP methods this handler responds to, ANY matches every method
 	Path              string
+	Status            int // Status is the status to respond with on success. 0 means the default for the response kind
 	Config            HandlerConfig
+	Response          ResponseDefinition
 	HandlerMethodName string
+	Pos               token.Pos // Pos is the position of the handler method's name
+}
+
+// SuccessStatus gets the status a handler responds with when it succeeds
+func (handler HandlerDefinition) SuccessStatus() int {
+	if handler.Status != 0 {
+		return handler.Status
+	}
+
+	if handler.Response.Kind == ResponseKindNone {
+		return 204
+	}
+
+	return 200
+}
+
+// ResponseKind describes how the value returned by a handler is sent
+type ResponseKind int
+
+const (
+	ResponseKindJSON    ResponseKind = iota // the value is sent as the body, encoded as JSON unless it's binary
+	ResponseKindWrapped                     // the value is a pkg.Response[T] or pointer to one, which also controls status and headers
+	ResponseKindNone                        // the handler only returns an error, so there is no body
+)
+
+// BodyEncoding describes how a response body is encoded
+type BodyEncoding int
+
+const (
+	BodyEncodingJSON   BodyEncoding = iota // the body is marshalled as JSON
+	BodyEncodingBytes                      // the body is a []byte, sent base64 encoded
+	BodyEncodingReader                     // the body is an io.Reader, read to the end and sent base64 encoded
+	BodyEncodingFile                       // the body is a pkg.FileResponse, which also sets the content type
+)
+
+// IsBinary checks if bodies with this encoding are sent base64 encoded
+func (encoding BodyEncoding) IsBinary() bool {
+	return encoding != BodyEncodingJSON
+}
+
+// ResponseDefinition describes the value returned by a handler
+type ResponseDefinition struct {
+	Kind     ResponseKind
+	Type     types.Type   // Type is the type returned by the handler. nil if there is no response value
+	BodyType types.Type   // BodyType is the type of the response body. For wrapped responses, this is T
+	Encoding BodyEncoding // Encoding is how the body is encoded
+	Pointer  bool         // Pointer is set if a wrapped response is returned as a *pkg.Response[T]
 }
 
 type HandlerConfig struct {
-	Type  *types.Named
-	Query []VariableDefinition
-	Path  []VariableDefinition
-	Body  VariableDefinition
+	Type    *types.Named // Type is the config struct, or nil if the handler doesn't take one
+	Query   []VariableDefinition
+	Path    []VariableDefinition
+	Headers []VariableDefinition
+	Body    VariableDefinition
+	Form    []VariableDefinition // Form are the value fields of a multipart/form-data body
+	Files   []VariableDefinition // Files are the files uploaded in a multipart/form-data body
+	Claims  []VariableDefinition // Claims are claims from the request authorizer
+}
+
+// IsMultipart checks if the handler reads a multipart/form-data body
+func (config HandlerConfig) IsMultipart() bool {
+	return len(config.Form) > 0 || len(config.Files) > 0
+}
+
+// HasConfig checks if the handler takes a request config parameter
+func (config HandlerConfig) HasConfig() bool {
+	return config.Type != nil
 }
 
 type VariableDefinition struct {
-	Name      string
-	Type      types.Type
-	FieldName string
+	Name      string            // Name is the key of this variable in the request, i.e. the path placeholder or header name
+	Type      types.Type        // Type is the type of the config field
+	FieldName string            // FieldName is the name of the config field this variable is assigned to
+	Options   map[string]string // Options are any additional options provided in the field tag
+	Converter types.Object      // Converter is an optional func(string) (T, error) used to convert raw values
+	ConvertTo types.Type        // ConvertTo is the T produced by Converter, or nil if there is no converter
+	Pos       token.Pos         // Pos is the position of the config field
+}
+
+// HasOption checks if the given tag option was provided for this variable
+func (variable VariableDefinition) HasOption(option string) bool {
+	_, ok := variable.Options[option]
+	return ok
 }
@@ -0,0 +1,44 @@
+package model
+
+import "fmt"
+
+// Target is the event source that invokes generated lambdas. It decides the request and response types handlers are
+// generated for.
+type Target string
+
+const (
+	TargetRestAPI     Target = "apigateway-rest" // API Gateway REST API, payload format 1.0
+	TargetHTTPAPI     Target = "apigateway-http" // API Gateway HTTP API, payload format 2.0
+	TargetALB         Target = "alb"             // Application Load Balancer target group
+	TargetFunctionURL Target = "function-url"    // Lambda function URL, which routes every request to one lambda
+)
+
+// DefaultTarget is used for services that don't select a target
+const DefaultTarget = TargetRestAPI
+
+// ParseTarget checks that a target name is supported
+func ParseTarget(target string) (Target, error) {
+	switch parsed := Target(target); parsed {
+	case TargetRestAPI, TargetHTTPAPI, TargetALB, TargetFunctionURL:
+		return parsed, nil
+	default:
+		return "", fmt.Errorf("target must be '%s', '%s', '%s', or '%s', but got '%s'", TargetRestAPI, TargetHTTPAPI, TargetALB, TargetFunctionURL, target)
+	}
+}
+
+// Routed checks if the target can't route requests to handlers itself, so its lambdas always have a generated router
+func (target Target) Routed() bool {
+	return target == TargetFunctionURL
+}
+
+// PayloadVersion gets the payload format version of the events this target sends. Empty if the target has no versions.
+func (target Target) PayloadVersion() string {
+	switch target {
+	case TargetRestAPI:
+		return "1.0"
+	case TargetHTTPAPI:
+		return "2.0"
+	default:
+		return ""
+	}
+}
@@ -1,33 +1,161 @@
 package output
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/softwaresale/lambdagen/internal/codegen"
+	"github.com/softwaresale/lambdagen/internal/diagnostics"
 	"github.com/softwaresale/lambdagen/internal/model"
+	"go/token"
 	"go/types"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 )
 
+// monolithLambdaName is the directory of the lambda that serves every service with monolith packaging
+const monolithLambdaName = "API"
+
+// sharedConfigKeys are the service config variables that apply to a whole lambda
+var sharedConfigKeys = []string{"expose_errors", "errors", "problem_types", "alb_multi_value_headers", "auth_type"}
+
 type outputNode struct {
 	serviceDef *model.ServiceDefinition
 	method     *model.HandlerDefinition
+	packaging  model.Packaging
+	services   []codegen.RoutedService // services are the services and handlers served by a routed lambda
+	consumer   *model.ConsumerDefinition
+}
+
+// position gets the file set and position that problems with the node's lambda are reported at
+func (node outputNode) position() (*token.FileSet, token.Pos) {
+	if node.consumer != nil {
+		return node.serviceDef.Pkg.Fset, node.consumer.Pos
+	}
+
+	if node.packaging.Routed() {
+		return node.services[0].Definition.Pkg.Fset, node.services[0].Handlers[0].Pos
+	}
+
+	return node.serviceDef.Pkg.Fset, node.method.Pos
+}
+
+// translate generates the main file of the node's lambda. Codegen panics on models that it can't translate, so panics
+// are recovered into errors instead of crashing halfway through the output.
+func (node outputNode) translate() (code []byte, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("%v", recovered)
+		}
+	}()
+
+	var buffer bytes.Buffer
+	if node.consumer != nil {
+		err = codegen.TranslateConsumer(&buffer, *node.serviceDef, *node.consumer)
+	} else if node.packaging.Routed() {
+		err = codegen.TranslateRouter(&buffer, node.services)
+	} else {
+		err = codegen.TranslateHandler(&buffer, *node.serviceDef, *node.method)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
 }
 
 func (node outputNode) Metadata() model.LambdaMetadata {
 
-	handlerPath := node.method.Path
-	basePath, ok := node.serviceDef.Config["base_path"]
-	if ok {
-		handlerPath = filepath.Join(basePath, handlerPath)
+	// consumers are invoked by an event source mapping instead of a route
+	if node.consumer != nil {
+		return model.LambdaMetadata{
+			EventSource: &model.EventSourceSpec{
+				Type:                    node.consumer.Source,
+				BatchSize:               node.consumer.BatchSize,
+				ReportBatchItemFailures: true,
+			},
+		}
+	}
+
+	// routed lambdas describe every route that they serve
+	if node.packaging.Routed() {
+		return node.routedMetadata()
+	}
+
+	handlerPath := node.serviceDef.HandlerPath(*node.method)
+
+	metadata := model.LambdaMetadata{
+		Path:           handlerPath,
+		Methods:        node.method.Methods,
+		Target:         node.serviceDef.Target,
+		PayloadVersion: node.serviceDef.Target.PayloadVersion(),
+	}
+
+	// method predates multi-method handlers, so it's still set when there is only one
+	if len(node.method.Methods) == 1 {
+		metadata.Method = node.method.Methods[0]
+	}
+
+	// load balancers route with listener rules instead of API routes
+	if node.serviceDef.Target == model.TargetALB {
+		metadata.Path = ""
+		metadata.ALBRule = albRule(node.serviceDef, *node.method)
+	}
+
+	return metadata
+}
+
+func (node outputNode) routedMetadata() model.LambdaMetadata {
+	// every service of a routed lambda shares its target
+	serviceDef := node.services[0].Definition
+
+	metadata := model.LambdaMetadata{
+		Target:         serviceDef.Target,
+		PayloadVersion: serviceDef.Target.PayloadVersion(),
+	}
+
+	if serviceDef.Target == model.TargetFunctionURL {
+		metadata.FunctionURL = &model.FunctionURLSpec{
+			AuthType: serviceDef.FunctionURLAuthType(),
+		}
+	}
+
+	for _, service := range node.services {
+		for _, method := range service.Handlers {
+			route := model.RouteMetadata{
+				Methods: method.Methods,
+				Path:    service.Definition.HandlerPath(method),
+			}
+
+			if serviceDef.Target == model.TargetALB {
+				route.ALBRule = albRule(service.Definition, method)
+			}
+
+			metadata.Routes = append(metadata.Routes, route)
+		}
 	}
 
-	return model.LambdaMetadata{
-		Path:   handlerPath,
-		Method: node.method.Method,
+	return metadata
+}
+
+// albRule gets the listener rule that routes a handler's requests to its lambda
+func albRule(serviceDef *model.ServiceDefinition, method model.HandlerDefinition) *model.ALBRule {
+	// the path was validated while parsing
+	template, _ := model.ParsePathTemplate(serviceDef.HandlerPath(method))
+
+	var methods []string
+	if !slices.Contains(method.Methods, "ANY") {
+		methods = method.Methods
+	}
+
+	return &model.ALBRule{
+		PathPatterns:      []string{model.ALBPathPattern(template)},
+		HTTPMethods:       methods,
+		MultiValueHeaders: serviceDef.ALBMultiValueHeaders(),
 	}
 }
 
@@ -35,7 +163,13 @@ func (node outputNode) Metadata() model.LambdaMetadata {
 type Manager struct {
 	baseOutputDir string
 	outputs       map[string]outputNode
-	uniquePaths   map[string]string
+	uniquePaths   map[string][]mappedMethod // uniquePaths maps each path to the methods already registered for it
+}
+
+// mappedMethod is a method of a path that a handler is registered for
+type mappedMethod struct {
+	method  string
+	handler string // handler is the service and method name of the handler that maps the method
 }
 
 func NewManager(rootModDir, lambdaDir string) *Manager {
@@ -45,6 +179,7 @@ func NewManager(rootModDir, lambdaDir string) *Manager {
 	return &Manager{
 		baseOutputDir: outputDir,
 		outputs:       make(map[string]outputNode),
+		uniquePaths:   make(map[string][]mappedMethod),
 	}
 }
 
@@ -52,26 +187,42 @@ func (output *Manager) CreateOutputDir() error {
 	return os.MkdirAll(output.baseOutputDir, 0755)
 }
 
-func (output *Manager) Render() error {
-	var err error
-	for outputPath, node := range output.outputs {
-		// make the output path
-		err = os.MkdirAll(outputPath, os.ModePerm)
+// Render writes every registered lambda. Every lambda is translated before anything is written, so lambdas that can't
+// be generated are reported to diags and leave the output untouched.
+func (output *Manager) Render(diags *diagnostics.Collector) error {
+	outputPaths := slices.Sorted(maps.Keys(output.outputs))
+
+	mainFiles := make(map[string][]byte, len(outputPaths))
+	for _, outputPath := range outputPaths {
+		node := output.outputs[outputPath]
+
+		code, err := node.translate()
 		if err != nil {
-			return err
+			fset, pos := node.position()
+			diags.Report(fset.Position(pos), diagnostics.SeverityError, diagnostics.CodeGenerationFailed, "failed to generate lambda %s: %s", filepath.Base(outputPath), err)
+			continue
 		}
 
-		outputFile, err := os.Create(filepath.Join(outputPath, "main.go"))
+		mainFiles[outputPath] = code
+	}
+
+	if len(mainFiles) != len(outputPaths) {
+		return nil
+	}
+
+	for _, outputPath := range outputPaths {
+		// make the output path
+		err := os.MkdirAll(outputPath, os.ModePerm)
 		if err != nil {
-			return fmt.Errorf("error while making main file: %w", err)
+			return err
 		}
 
-		err = codegen.TranslateHandler(outputFile, *node.serviceDef, *node.method)
+		err = os.WriteFile(filepath.Join(outputPath, "main.go"), mainFiles[outputPath], 0644)
 		if err != nil {
-			return fmt.Errorf("error while translating handler: %w", err)
+			return fmt.Errorf("error while writing main file: %w", err)
 		}
 
-		err = output.outputMetadata(node, outputPath)
+		err = output.outputMetadata(output.outputs[outputPath], outputPath)
 		if err != nil {
 			return fmt.Errorf("error while writing metadata: %w", err)
 		}
@@ -82,19 +233,10 @@ func (output *Manager) Render() error {
 
 func (output *Manager) outputMetadata(node outputNode, lambdaDir string) error {
 
-	// get an optional base path
-	basePath, ok := node.serviceDef.Config["base_path"]
-	if ok {
-		node.method.Path = filepath.Join(basePath, node.method.Path)
-	}
-
 	outputPath := filepath.Join(lambdaDir, "spec.json")
-	metadata := model.LambdaMetadata{
-		Method: node.method.Method,
-		Path:   node.method.Path,
-	}
+	metadata := node.Metadata()
 
-	bytes, err := json.Marshal(metadata)
+	metadataJSON, err := json.Marshal(metadata)
 	if err != nil {
 		return fmt.Errorf("error while marshalling data: %w", err)
 	}
@@ -111,7 +253,7 @@ func (output *Manager) outputMetadata(node outputNode, lambdaDir string) error {
 		}
 	}()
 
-	_, err = outputFile.Write(bytes)
+	_, err = outputFile.Write(metadataJSON)
 	if err != nil {
 		return fmt.Errorf("error while writing output file: %w", err)
 	}
@@ -124,7 +266,8 @@ func (output *Manager) outputMetadata(node outputNode, lambdaDir string) error {
 	return nil
 }
 
-// Register registers a service handler to be outputted
+// Register registers a service handler to be outputted. Conflicts with handlers that were already registered are
+// returned as a *diagnostics.Error at the handler.
 func (output *Manager) Register(serviceDef *model.ServiceDefinition, handler *model.HandlerDefinition) error {
 
 	handlerName, err := extractHandlerName(serviceDef)
@@ -133,30 +276,133 @@ func (output *Manager) Register(serviceDef *model.ServiceDefinition, handler *mo
 	}
 
 	// make the output dir
-	lambdaDirectoryName := strings.Join([]string{handlerName, handler.HandlerMethodName}, "_")
+	packaging := serviceDef.LambdaPackaging()
+	var lambdaDirectoryName string
+	switch packaging {
+	case model.PackagingService:
+		lambdaDirectoryName = handlerName
+	case model.PackagingMonolith:
+		lambdaDirectoryName = monolithLambdaName
+	default:
+		lambdaDirectoryName = strings.Join([]string{handlerName, handler.HandlerMethodName}, "_")
+	}
 	lambdaDirectoryPath := filepath.Join(output.baseOutputDir, lambdaDirectoryName)
 
-	if _, existing := output.uniquePaths[lambdaDirectoryPath]; existing {
-		return errors.New("lambda directory already exists")
+	// ensure that path is not mapped with any of the given methods. ANY overlaps with every method. Targets without
+	// routing give each lambda its own URL, so paths only conflict within the lambda
+	handlerPath := serviceDef.HandlerPath(*handler)
+	pathKey := handlerPath
+	if serviceDef.Target.Routed() {
+		pathKey = lambdaDirectoryName + " " + handlerPath
 	}
 
-	outputNode := outputNode{
-		serviceDef: serviceDef,
-		method:     handler,
+	qualifiedName := handlerName + "." + handler.HandlerMethodName
+	for _, existing := range output.uniquePaths[pathKey] {
+		for _, method := range handler.Methods {
+			if method == existing.method || method == "ANY" || existing.method == "ANY" {
+				return diagnostics.Errorf(handler.Pos, diagnostics.CodeRouteConflict, "path %s %s of %s is already mapped by %s", existing.method, handlerPath, qualifiedName, existing.handler)
+			}
+		}
+	}
+
+	node, existing := output.outputs[lambdaDirectoryPath]
+	if existing && (!packaging.Routed() || node.packaging != packaging) {
+		return diagnostics.Errorf(handler.Pos, diagnostics.CodeServiceConflict, "%s would be generated in lambda %s, which is already generated for another service", qualifiedName, lambdaDirectoryName)
 	}
 
-	// ensure that path is not mapped with the given method
-	method, ok := output.uniquePaths[outputNode.Metadata().Path]
-	if ok {
-		// this is mapped, if the methods are the same, then that's a problem
-		if method == outputNode.method.Method {
-			// problem
-			return fmt.Errorf("path %s %s is already mapped", method, outputNode.Metadata().Path)
+	if !packaging.Routed() {
+		node = outputNode{
+			serviceDef: serviceDef,
+			method:     handler,
+			packaging:  packaging,
+		}
+	} else {
+		node, err = addRoutedHandler(node, packaging, serviceDef, handler)
+		if err != nil {
+			return diagnostics.Errorf(handler.Pos, diagnostics.CodeServiceConflict, "%s can't be generated in lambda %s: %s", qualifiedName, lambdaDirectoryName, err)
 		}
 	}
 
 	// work out the output
-	output.outputs[lambdaDirectoryPath] = outputNode
+	output.outputs[lambdaDirectoryPath] = node
+	for _, method := range handler.Methods {
+		output.uniquePaths[pathKey] = append(output.uniquePaths[pathKey], mappedMethod{method: method, handler: qualifiedName})
+	}
+
+	return nil
+}
+
+// RegisterConsumer registers a service consumer to be outputted. Every consumer gets its own lambda, since each lambda
+// has a single event source mapping. Conflicts are returned as a *diagnostics.Error at the consumer.
+func (output *Manager) RegisterConsumer(serviceDef *model.ServiceDefinition, consumer *model.ConsumerDefinition) error {
+
+	handlerName, err := extractHandlerName(serviceDef)
+	if err != nil {
+		panic(err)
+	}
+
+	// make the output dir
+	lambdaDirectoryName := strings.Join([]string{handlerName, consumer.ConsumerMethodName}, "_")
+	lambdaDirectoryPath := filepath.Join(output.baseOutputDir, lambdaDirectoryName)
+
+	if _, existing := output.outputs[lambdaDirectoryPath]; existing {
+		return diagnostics.Errorf(consumer.Pos, diagnostics.CodeServiceConflict, "%s.%s would be generated in lambda %s, which is already generated for another service", handlerName, consumer.ConsumerMethodName, lambdaDirectoryName)
+	}
+
+	output.outputs[lambdaDirectoryPath] = outputNode{
+		serviceDef: serviceDef,
+		consumer:   consumer,
+	}
+
+	return nil
+}
+
+// addRoutedHandler adds a handler to the services served by a routed lambda
+func addRoutedHandler(node outputNode, packaging model.Packaging, serviceDef *model.ServiceDefinition, handler *model.HandlerDefinition) (outputNode, error) {
+	node.packaging = packaging
+
+	if len(node.services) > 0 {
+		err := checkSharedConfig(node.services[0].Definition, serviceDef)
+		if err != nil {
+			return node, err
+		}
+	}
+
+	serviceName, _ := extractHandlerName(serviceDef)
+	for idx := range node.services {
+		if node.services[idx].Definition == serviceDef {
+			node.services[idx].Handlers = append(node.services[idx].Handlers, *handler)
+			return node, nil
+		}
+
+		// generated names only use the type name, so services from different packages can't share it
+		if existingName, _ := extractHandlerName(node.services[idx].Definition); existingName == serviceName {
+			return node, fmt.Errorf("services %s and %s would share a lambda, but have the same name", node.services[idx].Definition.Type, serviceDef.Type)
+		}
+	}
+
+	node.services = append(node.services, codegen.RoutedService{
+		Definition: serviceDef,
+		Handlers:   []model.HandlerDefinition{*handler},
+	})
+
+	return node, nil
+}
+
+// checkSharedConfig ensures that services bundled into one lambda agree on the config that applies to the whole lambda
+func checkSharedConfig(first, other *model.ServiceDefinition) error {
+	firstName, _ := extractHandlerName(first)
+	otherName, _ := extractHandlerName(other)
+
+	if first.Target != other.Target {
+		return fmt.Errorf("services %s and %s share a lambda, but have different targets", firstName, otherName)
+	}
+
+	for _, key := range sharedConfigKeys {
+		if first.Config[key] != other.Config[key] {
+			return fmt.Errorf("services %s and %s share a lambda, but have different values for %s", firstName, otherName, key)
+		}
+	}
 
 	return nil
 }
@@ -0,0 +1,153 @@
+package output
+
+import (
+	"errors"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/softwaresale/lambdagen/internal/diagnostics"
+	"github.com/softwaresale/lambdagen/internal/model"
+	"golang.org/x/tools/go/packages"
+)
+
+// testFileSet and testFile position the test handlers. Each offset is on its own line.
+var testFileSet, testFile = func() (*token.FileSet, *token.File) {
+	fset := token.NewFileSet()
+	file := fset.AddFile("svc.go", -1, 100)
+	file.SetLinesForContent([]byte(strings.Repeat("\n", 100)))
+	return fset, file
+}()
+
+// testService builds a service definition without loading a package
+func testService(name string, packaging model.Packaging, config map[string]string) *model.ServiceDefinition {
+	pkg := types.NewPackage("example.com/svc", "svc")
+	typeName := types.NewTypeName(token.NoPos, pkg, name, nil)
+
+	return &model.ServiceDefinition{
+		Pkg:       &packages.Package{PkgPath: pkg.Path(), Fset: testFileSet},
+		Type:      types.NewNamed(typeName, types.NewStruct(nil, nil), nil),
+		Config:    config,
+		Target:    model.TargetRestAPI,
+		Packaging: packaging,
+	}
+}
+
+// testHandler builds a handler whose method name is declared on the given line of testFile
+func testHandler(name string, line int, method, path string) *model.HandlerDefinition {
+	return &model.HandlerDefinition{
+		Methods:           []string{method},
+		Path:              path,
+		HandlerMethodName: name,
+		Pos:               testFile.Pos(line - 1),
+	}
+}
+
+func TestRegisterConflicts(t *testing.T) {
+	tests := []struct {
+		name     string
+		register func(manager *Manager) error // register registers handlers, returning the error of the last one
+		wantCode diagnostics.Code
+		wantErr  string
+		wantLine int
+	}{
+		{
+			name: "same route",
+			register: func(manager *Manager) error {
+				service := testService("Orders", model.PackagingHandler, nil)
+				if err := manager.Register(service, testHandler("List", 3, "GET", "/orders")); err != nil {
+					return err
+				}
+
+				return manager.Register(service, testHandler("Search", 7, "GET", "/orders"))
+			},
+			wantCode: diagnostics.CodeRouteConflict,
+			wantErr:  "path GET /orders of Orders.Search is already mapped by Orders.List",
+			wantLine: 7,
+		},
+		{
+			name: "any overlaps",
+			register: func(manager *Manager) error {
+				service := testService("Orders", model.PackagingHandler, nil)
+				if err := manager.Register(service, testHandler("Create", 3, "POST", "/orders")); err != nil {
+					return err
+				}
+
+				return manager.Register(service, testHandler("Proxy", 9, "ANY", "/orders"))
+			},
+			wantCode: diagnostics.CodeRouteConflict,
+			wantErr:  "path POST /orders of Orders.Proxy is already mapped by Orders.Create",
+			wantLine: 9,
+		},
+		{
+			name: "shared lambda config",
+			register: func(manager *Manager) error {
+				orders := testService("Orders", model.PackagingMonolith, nil)
+				if err := manager.Register(orders, testHandler("List", 3, "GET", "/orders")); err != nil {
+					return err
+				}
+
+				users := testService("Users", model.PackagingMonolith, map[string]string{"expose_errors": "true"})
+				return manager.Register(users, testHandler("List", 12, "GET", "/users"))
+			},
+			wantCode: diagnostics.CodeServiceConflict,
+			wantErr:  "Users.List can't be generated in lambda API: services Orders and Users share a lambda, but have different values for expose_errors",
+			wantLine: 12,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.register(NewManager(t.TempDir(), "lambda"))
+
+			var diagErr *diagnostics.Error
+			if !errors.As(err, &diagErr) {
+				t.Fatalf("got error %v, want a *diagnostics.Error", err)
+			}
+
+			if diagErr.Code != test.wantCode {
+				t.Errorf("got code %s, want %s", diagErr.Code, test.wantCode)
+			}
+
+			if !strings.Contains(diagErr.Error(), test.wantErr) {
+				t.Errorf("got error %q, want it to contain %q", diagErr.Error(), test.wantErr)
+			}
+
+			if line := testFileSet.Position(diagErr.Pos).Line; line != test.wantLine {
+				t.Errorf("got line %d, want %d", line, test.wantLine)
+			}
+		})
+	}
+}
+
+func TestRenderReportsPanics(t *testing.T) {
+	rootDir := t.TempDir()
+	manager := NewManager(rootDir, "lambda")
+
+	// the service has no initializer, which codegen can't translate
+	service := testService("Orders", model.PackagingHandler, nil)
+	if err := manager.Register(service, testHandler("List", 5, "GET", "/orders")); err != nil {
+		t.Fatal(err)
+	}
+
+	diags := diagnostics.NewCollector()
+	if err := manager.Render(diags); err != nil {
+		t.Fatal(err)
+	}
+
+	reported := diags.Diagnostics()
+	if len(reported) != 1 || reported[0].Code != diagnostics.CodeGenerationFailed {
+		t.Fatalf("got diagnostics %v, want a single %s", reported, diagnostics.CodeGenerationFailed)
+	}
+
+	if reported[0].Pos.Line != 5 {
+		t.Errorf("got line %d, want 5", reported[0].Pos.Line)
+	}
+
+	if _, err := os.Stat(filepath.Join(rootDir, "lambda", "Orders_List")); !os.IsNotExist(err) {
+		t.Errorf("lambda was written despite the diagnostic: %v", err)
+	}
+}
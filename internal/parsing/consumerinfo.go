@@ -0,0 +1,70 @@
+package parsing
+
+import (
+	"errors"
+	"fmt"
+	"github.com/softwaresale/lambdagen/internal/model"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxSQSBatchSize is the largest batch an SQS event source mapping can send
+const maxSQSBatchSize = 10000
+
+// ConsumerInfo is the event source info provided in a consumer annotation
+type ConsumerInfo struct {
+	Source      model.EventSource // Source is the event source the consumer reads from
+	Concurrency int               // Concurrency is set with concurrency=<n>, and defaults to 1
+	BatchSize   int               // BatchSize is set with batch_size=<n>, or 0 if it isn't set
+}
+
+// ParseConsumerInfo pulls the event source info from the arg string for a consumer function. Options can follow the
+// source, i.e. sqs concurrency=4 batch_size=10
+func ParseConsumerInfo(args string) (ConsumerInfo, error) {
+	parser := regexp.MustCompile(`^\s*(\S+)(.*)`)
+	matches := parser.FindStringSubmatch(args)
+	if matches == nil {
+		return ConsumerInfo{}, errors.New("missing event source")
+	}
+
+	if model.EventSource(matches[1]) != model.EventSourceSQS {
+		return ConsumerInfo{}, fmt.Errorf("event source must be '%s', but got '%s'", model.EventSourceSQS, matches[1])
+	}
+
+	info := ConsumerInfo{
+		Source:      model.EventSource(matches[1]),
+		Concurrency: 1,
+	}
+
+	optionParser := regexp.MustCompile(`^([a-zA-Z_]\w*)=(\S+)$`)
+	for _, field := range strings.Fields(matches[2]) {
+		option := optionParser.FindStringSubmatch(field)
+		if option == nil {
+			return ConsumerInfo{}, fmt.Errorf("malformed consumer option '%s', options must be key=value", field)
+		}
+
+		switch option[1] {
+		case "concurrency":
+			concurrency, err := strconv.Atoi(option[2])
+			if err != nil || concurrency < 1 {
+				return ConsumerInfo{}, fmt.Errorf("concurrency must be a positive number, but got '%s'", option[2])
+			}
+
+			info.Concurrency = concurrency
+
+		case "batch_size":
+			batchSize, err := strconv.Atoi(option[2])
+			if err != nil || batchSize < 1 || batchSize > maxSQSBatchSize {
+				return ConsumerInfo{}, fmt.Errorf("batch_size must be between 1 and %d, but got '%s'", maxSQSBatchSize, option[2])
+			}
+
+			info.BatchSize = batchSize
+
+		default:
+			return ConsumerInfo{}, fmt.Errorf("unknown consumer option '%s'", option[1])
+		}
+	}
+
+	return info, nil
+}
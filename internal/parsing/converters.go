@@ -0,0 +1,135 @@
+package parsing
+
+import (
+	"fmt"
+	"github.com/softwaresale/lambdagen/internal/diagnostics"
+	"github.com/softwaresale/lambdagen/internal/model"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"golang.org/x/tools/go/packages"
+)
+
+// ConverterRegistry holds the functions annotated with lambdagen:converter in a package. Converters have the same shape
+// as pkg.RequestVarConvert, and are used for any request variable of their result type.
+type ConverterRegistry struct {
+	converters []types.Object
+}
+
+// findConverters finds all converter functions declared in the given package. Invalid converters are reported and left
+// out of the registry.
+func findConverters(pkg *packages.Package, diags *diagnostics.Collector) *ConverterRegistry {
+	registry := &ConverterRegistry{}
+	for _, syntax := range pkg.Syntax {
+		for _, decl := range syntax.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if decl.Doc == nil || decl.Recv != nil {
+					continue
+				}
+
+				role, found := model.ParseObjectRoleDocstring(decl.Doc.Text())
+				if !found || role.Type != model.ObjectRoleConverter {
+					continue
+				}
+
+				converterObj := pkg.TypesInfo.ObjectOf(decl.Name)
+				if _, err := converterResultType(converterObj); err != nil {
+					diags.Report(pkg.Fset.Position(decl.Name.Pos()), diagnostics.SeverityError, diagnostics.CodeInvalidConverter, "invalid converter %s: %s", converterObj.Name(), err)
+					continue
+				}
+
+				registry.converters = append(registry.converters, converterObj)
+
+			default:
+				continue
+			}
+		}
+	}
+
+	return registry
+}
+
+// Lookup finds a registered converter that produces the given type. Returns nil if there is none.
+func (registry *ConverterRegistry) Lookup(tp types.Type) types.Object {
+	for _, converter := range registry.converters {
+		resultType, _ := converterResultType(converter)
+		if types.Identical(resultType, tp) {
+			return converter
+		}
+	}
+
+	return nil
+}
+
+// converterResultType verifies that a converter has the signature func(string) (T, error), and gets T
+func converterResultType(converter types.Object) (types.Type, error) {
+	signature, ok := converter.Type().Underlying().(*types.Signature)
+	if !ok {
+		return nil, fmt.Errorf("expected function, but got %s", converter.Type().String())
+	}
+
+	params := signature.Params()
+	if params.Len() != 1 || !types.Identical(params.At(0).Type(), types.Typ[types.String]) {
+		return nil, fmt.Errorf("expected a single string parameter, but got %s", params.String())
+	}
+
+	results := signature.Results()
+	if results.Len() != 2 || !isErrorType(results.At(1).Type()) {
+		return nil, fmt.Errorf("expected results (T, error), but got %s", results.String())
+	}
+
+	return results.At(0).Type(), nil
+}
+
+// resolveConverter finds the converter to use for a variable. An explicit converter tag option takes precedence over
+// registered converters. Converters apply to the base type of a variable, so pointers and slices are unwrapped. Named
+// slices without a converter of their own take every value, so the elements of those are converted instead.
+func (parser *ServiceParser) resolveConverter(configType *types.Named, variable model.VariableDefinition) (types.Object, error) {
+	baseType := variableBaseType(variable.Type)
+
+	elemType := baseType
+	if sliceTp, ok := valuesSliceType(model.VariableDefinition{}, baseType); ok {
+		elemType = variableBaseType(sliceTp.Elem())
+	}
+
+	converterName, ok := variable.Options[model.TagOptionConverter]
+	if !ok {
+		if converter := parser.converters.Lookup(baseType); converter != nil {
+			return converter, nil
+		}
+
+		return parser.converters.Lookup(elemType), nil
+	}
+
+	// explicit converters are looked up next to the config struct
+	converter := configType.Obj().Pkg().Scope().Lookup(converterName)
+	if converter == nil {
+		return nil, diagnostics.Errorf(token.NoPos, diagnostics.CodeInvalidConverter, "converter %s not found for %s", converterName, variable.FieldName)
+	}
+
+	resultType, err := converterResultType(converter)
+	if err != nil {
+		return nil, diagnostics.Errorf(token.NoPos, diagnostics.CodeInvalidConverter, "invalid converter %s: %s", converterName, err)
+	}
+
+	if !types.Identical(resultType, baseType) && !types.Identical(resultType, elemType) {
+		return nil, diagnostics.Errorf(token.NoPos, diagnostics.CodeInvalidConverter, "converter %s produces %s, but %s requires %s", converterName, resultType.String(), variable.FieldName, baseType.String())
+	}
+
+	return converter, nil
+}
+
+// variableBaseType unwraps optional pointers and slices from a variable type
+func variableBaseType(tp types.Type) types.Type {
+	switch tp := types.Unalias(tp).(type) {
+	case *types.Pointer:
+		return variableBaseType(tp.Elem())
+
+	case *types.Slice:
+		return variableBaseType(tp.Elem())
+
+	default:
+		return tp
+	}
+}
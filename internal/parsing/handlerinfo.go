@@ -2,16 +2,88 @@ package parsing
 
 import (
 	"errors"
+	"fmt"
 	"regexp"
+	"strconv"
+	"strings"
 )
 
-// ParseHttpInfo pulls the handler route info from the arg string for a handler function
-func ParseHttpInfo(args string) (string, string, error) {
-	parser := regexp.MustCompile(`(GET|POST|PUT|PATCH|DELETE)\s+(\S+)`)
+const (
+	httpMethodAny = "ANY" // API Gateway's catch-all method
+)
+
+// HttpInfo is the route info provided in a handler annotation
+type HttpInfo struct {
+	Methods []string // Methods are the HTTP methods the handler responds to
+	Path    string   // Path is the handler path template
+	Status  int      // Status is the success status set with status=<code>, or 0 if it isn't set
+}
+
+// ParseHttpInfo pulls the handler route info from the arg string for a handler function. A handler can have several
+// methods separated by '|', i.e. GET|HEAD /items. Options can follow the path, i.e. POST /orders status=201
+func ParseHttpInfo(args string) (HttpInfo, error) {
+	parser := regexp.MustCompile(`^\s*((?:GET|POST|PUT|PATCH|DELETE|HEAD|OPTIONS|ANY)(?:\|\S+)*)\s+(\S+)(.*)`)
 	matches := parser.FindStringSubmatch(args)
 	if matches == nil {
-		return "", "", errors.New("failed to parse http info")
+		return HttpInfo{}, errors.New("failed to parse http info")
+	}
+
+	methods, err := parseHttpMethods(matches[1])
+	if err != nil {
+		return HttpInfo{}, err
+	}
+
+	info := HttpInfo{
+		Methods: methods,
+		Path:    matches[2],
+	}
+
+	optionParser := regexp.MustCompile(`^([a-zA-Z_]\w*)=(\S+)$`)
+	for _, field := range strings.Fields(matches[3]) {
+		option := optionParser.FindStringSubmatch(field)
+		if option == nil {
+			return HttpInfo{}, fmt.Errorf("malformed handler option '%s', options must be key=value", field)
+		}
+
+		switch option[1] {
+		case "status":
+			status, err := strconv.Atoi(option[2])
+			if err != nil || status < 100 || status > 599 {
+				return HttpInfo{}, fmt.Errorf("status must be an HTTP status code, but got '%s'", option[2])
+			}
+
+			info.Status = status
+
+		default:
+			return HttpInfo{}, fmt.Errorf("unknown handler option '%s'", option[1])
+		}
+	}
+
+	return info, nil
+}
+
+// parseHttpMethods splits and validates a '|' separated list of methods
+func parseHttpMethods(methodList string) ([]string, error) {
+	validMethod := regexp.MustCompile(`^(GET|POST|PUT|PATCH|DELETE|HEAD|OPTIONS|ANY)$`)
+
+	var methods []string
+	seen := make(map[string]bool)
+	for _, method := range strings.Split(methodList, "|") {
+		if !validMethod.MatchString(method) {
+			return nil, fmt.Errorf("unsupported http method '%s'", method)
+		}
+
+		if seen[method] {
+			return nil, fmt.Errorf("http method %s is listed more than once", method)
+		}
+
+		seen[method] = true
+		methods = append(methods, method)
+	}
+
+	if seen[httpMethodAny] && len(methods) > 1 {
+		return nil, fmt.Errorf("%s already matches every method, so it cannot be combined with others", httpMethodAny)
 	}
 
-	return matches[1], matches[2], nil
+	return methods, nil
 }
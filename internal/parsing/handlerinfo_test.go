@@ -0,0 +1,56 @@
+package parsing
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseHttpInfo(t *testing.T) {
+	tests := []struct {
+		args    string
+		want    HttpInfo
+		wantErr bool
+	}{
+		{args: "GET /orders", want: HttpInfo{Methods: []string{"GET"}, Path: "/orders"}},
+		{args: "  POST /orders", want: HttpInfo{Methods: []string{"POST"}, Path: "/orders"}},
+		{args: "GET|HEAD /orders/{id}", want: HttpInfo{Methods: []string{"GET", "HEAD"}, Path: "/orders/{id}"}},
+		{args: "POST /orders status=201", want: HttpInfo{Methods: []string{"POST"}, Path: "/orders", Status: 201}},
+		{args: "POST /orders   status=202  ", want: HttpInfo{Methods: []string{"POST"}, Path: "/orders", Status: 202}},
+		{args: "", wantErr: true},
+		{args: "/orders", wantErr: true},
+		{args: "GET", wantErr: true},
+		{args: "XGET /orders", wantErr: true},
+		{args: "GETX /orders", wantErr: true},
+		{args: "get /orders", wantErr: true},
+		{args: "GET|FETCH /orders", wantErr: true},
+		{args: "GET|GET /orders", wantErr: true},
+		{args: "ANY|GET /orders", wantErr: true},
+		{args: "POST /orders 201", wantErr: true},
+		{args: "POST /orders status=", wantErr: true},
+		{args: "POST /orders status=201x", wantErr: true},
+		{args: "POST /orders status=99", wantErr: true},
+		{args: "POST /orders 2status=201", wantErr: true},
+		{args: "POST /orders retries=3", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.args, func(t *testing.T) {
+			got, err := ParseHttpInfo(test.args)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("ParseHttpInfo(%q) = %+v, want an error", test.args, got)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ParseHttpInfo(%q) unexpected error: %v", test.args, err)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("ParseHttpInfo(%q) = %+v, want %+v", test.args, got, test.want)
+			}
+		})
+	}
+}
@@ -1,14 +1,16 @@
 package parsing
 
 import (
+	"errors"
 	"fmt"
 	"github.com/iancoleman/strcase"
+	"github.com/softwaresale/lambdagen/internal/diagnostics"
 	"github.com/softwaresale/lambdagen/internal/model"
 	"go/ast"
 	"go/token"
 	"go/types"
 	"golang.org/x/tools/go/packages"
-	"log"
+	"path"
 	"strings"
 )
 
@@ -16,9 +18,13 @@ type ServiceParser struct {
 	pkg        *packages.Package
 	syntax     *ast.File
 	commentMap ast.CommentMap
+	converters *ConverterRegistry
+	diags      *diagnostics.Collector
 }
 
-func ParseServices(basePath, modulePath string) ([]model.ServiceDefinition, error) {
+// ParseServices loads the given module and finds all services in it. Problems with individual services or handlers are
+// reported to diags, and the offending definition is left out. An error is only returned if loading fails entirely.
+func ParseServices(basePath, modulePath string, diags *diagnostics.Collector) ([]model.ServiceDefinition, error) {
 	packageConfig := packages.Config{
 		Mode: packages.LoadSyntax,
 		Dir:  basePath,
@@ -31,6 +37,18 @@ func ParseServices(basePath, modulePath string) ([]model.ServiceDefinition, erro
 
 	var serviceDefinitions []model.ServiceDefinition
 	for _, pkg := range srcPackages {
+		// type information is unreliable for broken packages, so don't try to parse them
+		if len(pkg.Errors) > 0 {
+			for _, pkgErr := range pkg.Errors {
+				diags.ReportPos(pkgErr.Pos, diagnostics.SeverityError, diagnostics.CodePackageError, "%s", pkgErr.Msg)
+			}
+
+			continue
+		}
+
+		// converters can be declared anywhere in the package
+		converters := findConverters(pkg, diags)
+
 		for _, syntax := range pkg.Syntax {
 
 			commentMap := ast.NewCommentMap(pkg.Fset, syntax, syntax.Comments)
@@ -39,6 +57,8 @@ func ParseServices(basePath, modulePath string) ([]model.ServiceDefinition, erro
 				pkg:        pkg,
 				syntax:     syntax,
 				commentMap: commentMap,
+				converters: converters,
+				diags:      diags,
 			}
 
 			handlers, err := parser.parseServiceDefinitions(syntax.Decls)
@@ -107,11 +127,11 @@ func (parser *ServiceParser) parseServiceDefinitions(decls []ast.Decl) ([]model.
 
 	var services []model.ServiceDefinition
 	for _, handler := range serviceHandlerObjects {
-		fmt.Printf("got handler: %s\n", handler.Obj.String())
+		parser.report(handler.Obj.Pos(), diagnostics.SeverityInfo, diagnostics.CodeServiceFound, "found service %s", handler.Obj.Name())
 
 		service, err := parser.parseServiceDefinition(handler)
 		if err != nil {
-			fmt.Printf("while parsing service def:\n%e\n", err)
+			parser.reportError(handler.Obj.Pos(), diagnostics.CodeMissingInitializer, err)
 			continue
 		}
 
@@ -123,37 +143,90 @@ func (parser *ServiceParser) parseServiceDefinitions(decls []ast.Decl) ([]model.
 
 func (parser *ServiceParser) parseServiceDefinition(handlerObj ServiceHandlerInfo) (model.ServiceDefinition, error) {
 
+	err := validateServiceConfig(handlerObj.Config)
+	if err != nil {
+		return model.ServiceDefinition{}, diagnostics.Errorf(handlerObj.Obj.Pos(), diagnostics.CodeInvalidServiceCfg, "invalid config for service %s: %s", handlerObj.Obj.Name(), err)
+	}
+
 	// find the service initializer
 	serviceInit := parser.findServiceInitializer(handlerObj.Obj)
 	if serviceInit == nil {
-		return model.ServiceDefinition{}, fmt.Errorf("service has now initializer for %s", handlerObj.Obj.String())
+		return model.ServiceDefinition{}, fmt.Errorf("service %s has no initializer", handlerObj.Obj.Name())
 	}
 
 	initializerFunctionObj := parser.pkg.TypesInfo.ObjectOf(serviceInit.Name)
 
 	// we know the type, let's find the handlers
-	handlerDecls := parser.extractHandlerMethods(handlerObj.Obj)
+	handlerDecls := parser.extractHandlerMethods(handlerObj.Obj, model.ObjectRoleHandlerTp)
 
 	var handlerDefs []model.HandlerDefinition
 	for _, decl := range handlerDecls {
-		def, err := parser.mapHandlerFunction(decl)
+		def, err := parser.mapHandlerFunction(handlerObj, decl)
 		if err != nil {
-			fmt.Printf("while parsing handler def:\n%e\n", err)
+			parser.reportError(decl.Name.Pos(), diagnostics.CodeInvalidHandlerCfg, err)
 			continue
 		}
 
 		handlerDefs = append(handlerDefs, def)
 	}
 
+	// and the queue consumers
+	var consumerDefs []model.ConsumerDefinition
+	for _, decl := range parser.extractHandlerMethods(handlerObj.Obj, model.ObjectRoleConsumer) {
+		def, err := parser.mapConsumerFunction(handlerObj, decl)
+		if err != nil {
+			parser.reportError(decl.Name.Pos(), diagnostics.CodeInvalidConsumerSig, err)
+			continue
+		}
+
+		consumerDefs = append(consumerDefs, def)
+	}
+
 	return model.ServiceDefinition{
-		Pkg:      parser.pkg,
-		Type:     handlerObj.Obj.Type(),
-		Init:     initializerFunctionObj,
-		Handlers: handlerDefs,
-		Config:   handlerObj.Config,
+		Pkg:       parser.pkg,
+		Type:      handlerObj.Obj.Type(),
+		Init:      initializerFunctionObj,
+		Handlers:  handlerDefs,
+		Consumers: consumerDefs,
+		Config:    handlerObj.Config,
+		Target:    model.Target(handlerObj.Config["target"]),
+		Packaging: model.Packaging(handlerObj.Config["packaging"]),
 	}, nil
 }
 
+// validateServiceConfig checks the values of service config variables that only accept specific values
+func validateServiceConfig(config map[string]string) error {
+	if errorFormat, ok := config["errors"]; ok && errorFormat != "api" && errorFormat != "problem" {
+		return fmt.Errorf("errors must be 'api' or 'problem', but got '%s'", errorFormat)
+	}
+
+	if exposeErrors, ok := config["expose_errors"]; ok && exposeErrors != "true" && exposeErrors != "false" {
+		return fmt.Errorf("expose_errors must be 'true' or 'false', but got '%s'", exposeErrors)
+	}
+
+	if multiValue, ok := config["alb_multi_value_headers"]; ok && multiValue != "true" && multiValue != "false" {
+		return fmt.Errorf("alb_multi_value_headers must be 'true' or 'false', but got '%s'", multiValue)
+	}
+
+	if authType, ok := config["auth_type"]; ok && authType != "NONE" && authType != "AWS_IAM" {
+		return fmt.Errorf("auth_type must be 'NONE' or 'AWS_IAM', but got '%s'", authType)
+	}
+
+	if target, ok := config["target"]; ok {
+		if _, err := model.ParseTarget(target); err != nil {
+			return err
+		}
+	}
+
+	if packaging, ok := config["packaging"]; ok {
+		if _, err := model.ParsePackaging(packaging); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (parser *ServiceParser) findServiceInitializer(handlerObj types.Object) *ast.FuncDecl {
 	for _, decl := range parser.syntax.Decls {
 		switch decl := decl.(type) {
@@ -169,8 +242,10 @@ func (parser *ServiceParser) findServiceInitializer(handlerObj types.Object) *as
 
 			// make sure that the return type is the service handler tp
 			err := parser.validateInitializerReturns(handlerObj, decl.Type.Results)
-			if err != nil {
-				fmt.Printf("warning: %e\n", err)
+			if errors.Is(err, errInitializerForOtherService) {
+				continue
+			} else if err != nil {
+				parser.report(decl.Name.Pos(), diagnostics.SeverityWarning, diagnostics.CodeInvalidInitializer, "%s is not an initializer for %s: %s", decl.Name.Name, handlerObj.Name(), err)
 				continue
 			}
 
@@ -185,6 +260,9 @@ func (parser *ServiceParser) findServiceInitializer(handlerObj types.Object) *as
 	return nil
 }
 
+// errInitializerForOtherService means that an initializer is valid, but for a different service
+var errInitializerForOtherService = errors.New("initializer is for another service")
+
 func (parser *ServiceParser) validateInitializerReturns(handlerObj types.Object, results *ast.FieldList) error {
 	if results.NumFields() != 2 {
 		return fmt.Errorf("expected 2 return values, but got %d", results.NumFields())
@@ -194,7 +272,7 @@ func (parser *ServiceParser) validateInitializerReturns(handlerObj types.Object,
 	switch returnType := returnType.(type) {
 	case *types.Pointer:
 		if returnType.Elem() != handlerObj.Type() {
-			return fmt.Errorf("expected return value of type %s, but got %s", handlerObj.Type(), returnType.Elem())
+			return fmt.Errorf("%w: expected return value of type %s, but got %s", errInitializerForOtherService, handlerObj.Type(), returnType.Elem())
 		}
 
 	case *types.Named:
@@ -217,20 +295,16 @@ func (parser *ServiceParser) validateInitializerReturns(handlerObj types.Object,
 	return nil
 }
 
-func (parser *ServiceParser) extractHandlerMethods(handlerObj types.Object) []*ast.FuncDecl {
-	handlerMethodSet := types.NewMethodSet(handlerObj.Type())
-
-	var handlerDecls []*ast.FuncDecl
-
-	handlerDecls = append(handlerDecls, parser.findHandlerMethods(handlerMethodSet)...)
-
+// extractHandlerMethods finds the methods of a service that are annotated with the given role
+func (parser *ServiceParser) extractHandlerMethods(handlerObj types.Object, role string) []*ast.FuncDecl {
+	// the pointer method set also contains all value methods, so this finds every handler exactly once
 	handlerPtrType := types.NewPointer(handlerObj.Type())
 	ptrHandlerSet := types.NewMethodSet(handlerPtrType)
 
-	handlerDecls = append(handlerDecls, parser.findHandlerMethods(ptrHandlerSet)...)
+	handlerDecls := parser.findHandlerMethods(ptrHandlerSet)
 
 	// get handler specs for everything
-	return filterMethods(handlerDecls)
+	return filterMethods(handlerDecls, role)
 }
 
 func (parser *ServiceParser) findHandlerMethods(methodSet *types.MethodSet) []*ast.FuncDecl {
@@ -240,25 +314,32 @@ func (parser *ServiceParser) findHandlerMethods(methodSet *types.MethodSet) []*a
 		method := methodSet.At(i)
 		methodPos := method.Obj().Pos()
 
+		// promoted methods from other packages can't be annotated, so they can't be handlers
+		if method.Obj().Pkg() != parser.pkg.Types {
+			continue
+		}
+
+		// methods can be declared in any file of the package
 		var methodDecl *ast.FuncDecl
+		for _, syntax := range parser.pkg.Syntax {
+			for _, decl := range syntax.Decls {
 
-		for _, decl := range parser.syntax.Decls {
+				switch decl := decl.(type) {
+				case *ast.FuncDecl:
+					declPos := decl.Name.Pos()
+					if methodPos == declPos {
+						methodDecl = decl
+					}
 
-			switch decl := decl.(type) {
-			case *ast.FuncDecl:
-				declPos := decl.Name.Pos()
-				if methodPos == declPos {
-					methodDecl = decl
-					break
+				default:
+					continue
 				}
-
-			default:
-				continue
 			}
 		}
 
 		if methodDecl == nil {
-			log.Fatalf("failed to find declaration info for %s\n", method.Obj().Name())
+			parser.report(methodPos, diagnostics.SeverityError, diagnostics.CodeMissingDeclaration, "failed to find declaration info for %s", method.Obj().Name())
+			continue
 		}
 
 		decls = append(decls, methodDecl)
@@ -267,90 +348,431 @@ func (parser *ServiceParser) findHandlerMethods(methodSet *types.MethodSet) []*a
 	return decls
 }
 
-func (parser *ServiceParser) mapHandlerFunction(handlerFunc *ast.FuncDecl) (model.HandlerDefinition, error) {
-	// verify that the first arg is the context type
+func (parser *ServiceParser) mapHandlerFunction(service ServiceHandlerInfo, handlerFunc *ast.FuncDecl) (model.HandlerDefinition, error) {
 	role, valid := model.ParseObjectRoleDocstring(handlerFunc.Doc.Text())
 	if !valid {
-		return model.HandlerDefinition{}, fmt.Errorf("invalid role for %s", handlerFunc.Name.String())
+		return model.HandlerDefinition{}, diagnostics.Errorf(handlerFunc.Doc.Pos(), diagnostics.CodeInvalidHandlerRoute, "invalid role for %s", handlerFunc.Name.String())
 	}
 
 	// parse the arg for handler stuff
-	httpMethod, endpoint, err := ParseHttpInfo(role.Args)
+	httpInfo, err := ParseHttpInfo(role.Args)
+	if err != nil {
+		return model.HandlerDefinition{}, diagnostics.Errorf(handlerFunc.Doc.Pos(), diagnostics.CodeInvalidHandlerRoute, "invalid route '%s' for %s: %s", role.Args, handlerFunc.Name.String(), err)
+	}
+
+	// verify that we can actually call this handler
+	signature, err := parser.validateHandlerSignature(service.Obj, handlerFunc)
+	if err != nil {
+		return model.HandlerDefinition{}, err
+	}
+
+	handlerConfig, err := parser.extractHandlerConfig(signature.Config)
 	if err != nil {
-		return model.HandlerDefinition{}, fmt.Errorf("error while parsing endpoitn info: %w", err)
+		return model.HandlerDefinition{}, err
+	}
+
+	// the base path is part of the route, so it can have placeholders too
+	fullPath := httpInfo.Path
+	if basePath, ok := service.Config["base_path"]; ok {
+		fullPath = path.Join(basePath, httpInfo.Path)
 	}
 
-	handlerConfig, err := parser.extractHandlerConfig(handlerFunc)
+	err = validatePathVariables(handlerFunc, fullPath, handlerConfig)
 	if err != nil {
-		return model.HandlerDefinition{}, fmt.Errorf("error while finding handler config: %w", err)
+		return model.HandlerDefinition{}, err
 	}
 
 	return model.HandlerDefinition{
-		Method:            httpMethod,
-		Path:              endpoint,
+		Methods:           httpInfo.Methods,
+		Path:              httpInfo.Path,
+		Status:            httpInfo.Status,
 		Config:            handlerConfig,
+		Response:          signature.Response,
 		HandlerMethodName: handlerFunc.Name.String(),
+		Pos:               handlerFunc.Name.Pos(),
 	}, nil
 }
 
-func (parser *ServiceParser) extractHandlerConfig(handlerFunc *ast.FuncDecl) (model.HandlerConfig, error) {
-	// second arg must be the config
-	// TODO this needs to be validated first...
-	configStructArg := handlerFunc.Type.Params.List[1]
+func (parser *ServiceParser) mapConsumerFunction(service ServiceHandlerInfo, consumerFunc *ast.FuncDecl) (model.ConsumerDefinition, error) {
+	role, _ := model.ParseObjectRoleDocstring(consumerFunc.Doc.Text())
 
-	argType := parser.pkg.TypesInfo.TypeOf(configStructArg.Type)
+	// parse the arg for event source stuff
+	consumerInfo, err := ParseConsumerInfo(role.Args)
+	if err != nil {
+		return model.ConsumerDefinition{}, diagnostics.Errorf(consumerFunc.Doc.Pos(), diagnostics.CodeInvalidConsumerSource, "invalid consumer annotation '%s' for %s: %s", role.Args, consumerFunc.Name.String(), err)
+	}
 
-	var handlerConfig model.HandlerConfig
-	switch argType := argType.(type) {
-	case *types.Named:
-		structTp, ok := argType.Underlying().(*types.Struct)
-		if !ok {
-			return model.HandlerConfig{}, fmt.Errorf("expected struct, got %s", argType.String())
+	// verify that we can actually call this consumer
+	signature, err := parser.validateConsumerSignature(service.Obj, consumerFunc)
+	if err != nil {
+		return model.ConsumerDefinition{}, err
+	}
+
+	return model.ConsumerDefinition{
+		Source:             consumerInfo.Source,
+		MessageType:        signature.MessageType,
+		Encoding:           signature.Encoding,
+		Concurrency:        consumerInfo.Concurrency,
+		BatchSize:          consumerInfo.BatchSize,
+		ConsumerMethodName: consumerFunc.Name.String(),
+		Pos:                consumerFunc.Name.Pos(),
+	}, nil
+}
+
+func (parser *ServiceParser) extractHandlerConfig(configType *types.Named) (model.HandlerConfig, error) {
+	// handlers without a config parameter don't read anything from the request
+	if configType == nil {
+		return model.HandlerConfig{}, nil
+	}
+
+	structTp := configType.Underlying().(*types.Struct)
+
+	handlerConfig := model.HandlerConfig{
+		Type: configType,
+	}
+
+	for i := range structTp.NumFields() {
+		field := structTp.Field(i)
+		tag := structTp.Tag(i)
+
+		role, valid := model.ParseObjectRoleTag(tag)
+		if !valid {
+			continue
 		}
 
-		handlerConfig.Type = argType
+		// role
+		if !isRequestVariableRole(role.Type) {
+			return model.HandlerConfig{}, diagnostics.Errorf(field.Pos(), diagnostics.CodeInvalidHandlerCfg, "invalid role '%s' for field %s", role.Type, field.Name())
+		}
 
-		for i := range structTp.NumFields() {
-			field := structTp.Field(i)
-			tag := structTp.Tag(i)
+		// name. Path and query variables are always named after their field
+		tagName := getVariableName(role.Args)
+		if len(tagName) == 0 || role.Type == model.ObjectRolePathVar || role.Type == model.ObjectRoleQueryParam {
+			tagName = strcase.ToLowerCamel(field.Name())
+		}
 
-			role, valid := model.ParseObjectRoleTag(tag)
-			if !valid {
-				continue
+		def := model.VariableDefinition{
+			Name:      tagName,
+			Type:      field.Type(),
+			FieldName: field.Name(),
+			Options:   role.GetTagOptions(),
+			Pos:       field.Pos(),
+		}
+
+		converter, err := parser.resolveConverter(configType, def)
+		if err != nil {
+			return model.HandlerConfig{}, withPos(err, field.Pos())
+		}
+
+		// converters were checked to produce the variable's base type, or the base type of its elements
+		if converter != nil {
+			def.Converter = converter
+			def.ConvertTo, _ = converterResultType(converter)
+		}
+
+		// the config is built with a composite literal in the generated package
+		if !field.Exported() {
+			return model.HandlerConfig{}, diagnostics.Errorf(field.Pos(), diagnostics.CodeInvalidHandlerCfg, "%s field %s must be exported", role.Type, field.Name())
+		}
+
+		if err := validateVariableType(role.Type, def); err != nil {
+			return model.HandlerConfig{}, diagnostics.Errorf(field.Pos(), diagnostics.CodeInvalidHandlerCfg, "invalid %s field %s: %s", role.Type, field.Name(), err)
+		}
+
+		switch role.Type {
+		case model.ObjectRolePathVar:
+			// a route only matches when every placeholder is present, so path variables are never missing
+			if _, ok := types.Unalias(field.Type()).(*types.Pointer); ok {
+				return model.HandlerConfig{}, diagnostics.Errorf(field.Pos(), diagnostics.CodeInvalidHandlerCfg, "pathvar field %s can't be a pointer, path variables are always present", field.Name())
 			}
 
-			// role
-			if !(role.Type == model.ObjectRolePathVar || role.Type == model.ObjectRoleQueryParam || role.Type == model.ObjectRoleBody) {
-				return model.HandlerConfig{}, fmt.Errorf("invalid role '%s' for %s", role.Type, field.String())
+			handlerConfig.Path = append(handlerConfig.Path, def)
+		case model.ObjectRoleQueryParam:
+			handlerConfig.Query = append(handlerConfig.Query, def)
+		case model.ObjectRoleHeader:
+			handlerConfig.Headers = append(handlerConfig.Headers, def)
+		case model.ObjectRoleBody:
+			if err := validateBodyType(field.Type()); err != nil {
+				return model.HandlerConfig{}, diagnostics.Errorf(field.Pos(), diagnostics.CodeInvalidHandlerCfg, "invalid body field %s: %s", field.Name(), err)
 			}
 
-			// name
-			tagName := getVariableName(role.Args)
-			if len(tagName) == 0 || role.Type != model.ObjectRoleBody {
-				tagName = strcase.ToLowerCamel(field.Name())
+			handlerConfig.Body = def
+		case model.ObjectRoleFormVar:
+			handlerConfig.Form = append(handlerConfig.Form, def)
+		case model.ObjectRoleClaim:
+			handlerConfig.Claims = append(handlerConfig.Claims, def)
+		case model.ObjectRoleFile:
+			if err := validateFileField(def); err != nil {
+				return model.HandlerConfig{}, diagnostics.Errorf(field.Pos(), diagnostics.CodeInvalidHandlerCfg, "invalid file field %s: %s", field.Name(), err)
 			}
 
-			def := model.VariableDefinition{
-				Name:      tagName,
-				Type:      field.Type(),
-				FieldName: field.Name(),
+			handlerConfig.Files = append(handlerConfig.Files, def)
+		}
+	}
+
+	// a request only has one body, so it's either decoded whole or as a multipart form
+	if len(handlerConfig.Body.Name) > 0 && handlerConfig.IsMultipart() {
+		return model.HandlerConfig{}, diagnostics.Errorf(handlerConfig.Body.Pos, diagnostics.CodeInvalidHandlerCfg, "body field %s can't be combined with file or formvar fields", handlerConfig.Body.FieldName)
+	}
+
+	return handlerConfig, nil
+}
+
+// isRequestVariableRole checks if a role can be used on a config field
+func isRequestVariableRole(role string) bool {
+	switch role {
+	case model.ObjectRolePathVar, model.ObjectRoleQueryParam, model.ObjectRoleHeader, model.ObjectRoleBody, model.ObjectRoleFormVar, model.ObjectRoleFile, model.ObjectRoleClaim:
+		return true
+	default:
+		return false
+	}
+}
+
+// textUnmarshalerType is encoding.TextUnmarshaler
+var textUnmarshalerType = types.NewInterfaceType([]*types.Func{
+	types.NewFunc(token.NoPos, nil, "UnmarshalText", types.NewSignatureType(nil, nil, nil,
+		types.NewTuple(types.NewParam(token.NoPos, nil, "text", types.NewSlice(types.Typ[types.Byte]))),
+		types.NewTuple(types.NewParam(token.NoPos, nil, "", types.Universe.Lookup("error").Type())),
+		false,
+	)),
+}, nil).Complete()
+
+// validateVariableType checks that the raw string values of a request variable can be converted into its field type.
+// Query and form variables can also be slices, which take every value of a repeated key. Body and file fields are
+// checked separately.
+func validateVariableType(role string, variable model.VariableDefinition) error {
+	tp := types.Unalias(variable.Type)
+
+	switch role {
+	case model.ObjectRoleBody, model.ObjectRoleFile:
+		return nil
+
+	// repeated keys and headers bind every value to slices. Path variables and claims only have a single value
+	case model.ObjectRoleQueryParam, model.ObjectRoleFormVar, model.ObjectRoleHeader:
+		if sliceTp, ok := valuesSliceType(variable, tp); ok {
+			return validateConvertibleType(variable, sliceTp.Elem())
+		}
+
+		// a missing key is already an empty slice, so there's nothing for a pointer to add
+		if ptrTp, ok := tp.(*types.Pointer); ok {
+			if _, ok := valuesSliceType(variable, ptrTp.Elem()); ok {
+				return fmt.Errorf("%s can't be bound, use %s instead, which is empty when the %s is missing", variable.Type.String(), ptrTp.Elem().String(), role)
 			}
+		}
+	}
+
+	return validateConvertibleType(variable, tp)
+}
+
+// valuesSliceType gets the slice that a variable of type tp is built as when it takes every value of a key. Named
+// slices are built like their underlying slice, unless a converter or encoding.TextUnmarshaler converts the whole type
+// from a single value. This matches codegen.
+func valuesSliceType(variable model.VariableDefinition, tp types.Type) (*types.Slice, bool) {
+	tp = types.Unalias(tp)
+	if variable.Converter != nil && types.Identical(variable.ConvertTo, tp) {
+		return nil, false
+	}
+
+	if named, ok := tp.(*types.Named); ok {
+		if types.Implements(types.NewPointer(named), textUnmarshalerType) {
+			return nil, false
+		}
+
+		tp = named.Underlying()
+	}
+
+	sliceTp, ok := tp.(*types.Slice)
+	return sliceTp, ok
+}
+
+// validateConvertibleType checks that generated code can convert a single raw string into tp, either with the
+// variable's converter, a built-in conversion, or encoding.TextUnmarshaler
+func validateConvertibleType(variable model.VariableDefinition, tp types.Type) error {
+	tp = types.Unalias(tp)
+	if variable.Converter != nil && types.Identical(variable.ConvertTo, tp) {
+		return nil
+	}
+
+	switch tp := tp.(type) {
+	case *types.Basic:
+		switch tp.Kind() {
+		case types.Bool, types.String, types.Float32, types.Float64,
+			types.Int, types.Int8, types.Int16, types.Int32, types.Int64,
+			types.Uint, types.Uint8, types.Uint16, types.Uint32, types.Uint64:
+			return nil
+		default:
+			return fmt.Errorf("%s can't be converted from a string", tp.String())
+		}
+
+	case *types.Named:
+		if tp.Obj().Pkg() != nil && !tp.Obj().Exported() {
+			return fmt.Errorf("%s must be exported", tp.String())
+		}
+
+		// this covers time.Time and the common UUID types too
+		if types.Implements(types.NewPointer(tp), textUnmarshalerType) {
+			return nil
+		}
+
+		// named basic types are converted through their underlying type
+		if underlying, ok := tp.Underlying().(*types.Basic); ok {
+			return validateConvertibleType(model.VariableDefinition{}, underlying)
+		}
+
+		return fmt.Errorf("%s needs a converter or must implement encoding.TextUnmarshaler", tp.String())
+
+	case *types.Pointer:
+		return validateConvertibleType(variable, tp.Elem())
+
+	default:
+		return fmt.Errorf("%s can't be converted from a string without a converter", tp.String())
+	}
+}
+
+// validateFileField checks that a file field is a pkg.UploadedFile, optionally behind a pointer or in a slice, and that
+// its max size is valid
+func validateFileField(variable model.VariableDefinition) error {
+	fileType := variable.Type
+	switch tp := fileType.(type) {
+	case *types.Pointer:
+		fileType = tp.Elem()
+	case *types.Slice:
+		fileType = tp.Elem()
+	}
+
+	named, ok := fileType.(*types.Named)
+	if !ok || !isRuntimeType(named, "UploadedFile") {
+		return fmt.Errorf("expected pkg.UploadedFile, *pkg.UploadedFile, or []pkg.UploadedFile, but got %s", variable.Type.String())
+	}
+
+	if maxSize, ok := variable.Options[model.TagOptionMaxSize]; ok {
+		if _, err := model.ParseByteSize(maxSize); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateBodyType checks that a body can be declared in generated code and decoded from JSON
+func validateBodyType(tp types.Type) error {
+	switch tp := tp.(type) {
+	case *types.Basic:
+		if tp.Kind() == types.UnsafePointer || tp.Info()&types.IsComplex != 0 {
+			return fmt.Errorf("%s can't be decoded from JSON", tp.String())
+		}
+
+		return nil
+
+	case *types.Named:
+		if tp.Obj().Pkg() != nil && !tp.Obj().Exported() {
+			return fmt.Errorf("%s must be exported", tp.String())
+		}
 
-			switch role.Type {
-			case model.ObjectRolePathVar:
-				handlerConfig.Path = append(handlerConfig.Path, def)
-			case model.ObjectRoleQueryParam:
-				handlerConfig.Query = append(handlerConfig.Query, def)
-			case model.ObjectRoleBody:
-				handlerConfig.Body = def
+		for i := range tp.TypeArgs().Len() {
+			if err := validateBodyType(tp.TypeArgs().At(i)); err != nil {
+				return err
 			}
 		}
 
+		switch tp.Underlying().(type) {
+		case *types.Chan, *types.Signature:
+			return fmt.Errorf("%s can't be decoded from JSON", tp.String())
+		}
+
+		return nil
+
+	case *types.Alias:
+		if tp.Obj().Pkg() != nil && !tp.Obj().Exported() {
+			return fmt.Errorf("%s must be exported", tp.String())
+		}
+
+		return nil
+
+	case *types.Interface:
+		if !tp.Empty() {
+			return fmt.Errorf("only empty interfaces can be decoded from JSON, but got %s", tp.String())
+		}
+
+		return nil
+
+	case *types.Pointer:
+		return validateBodyType(tp.Elem())
+
+	case *types.Slice:
+		return validateBodyType(tp.Elem())
+
+	case *types.Array:
+		return validateBodyType(tp.Elem())
+
+	case *types.Map:
+		if err := validateBodyType(tp.Key()); err != nil {
+			return err
+		}
+
+		return validateBodyType(tp.Elem())
+
 	default:
-		return model.HandlerConfig{}, fmt.Errorf("expected named type to be named, but got %s", argType.String())
+		return fmt.Errorf("%s can't be decoded from JSON", tp.String())
 	}
+}
 
-	return handlerConfig, nil
+// validatePathVariables checks that every placeholder in a handler path has a pathvar field, and that every pathvar
+// field has a placeholder
+func validatePathVariables(handlerFunc *ast.FuncDecl, fullPath string, config model.HandlerConfig) error {
+	template, err := model.ParsePathTemplate(fullPath)
+	if err != nil {
+		return diagnostics.Errorf(handlerFunc.Doc.Pos(), diagnostics.CodeInvalidHandlerRoute, "invalid path for %s: %s", handlerFunc.Name.Name, err)
+	}
+
+	var mismatches []error
+	for _, placeholder := range template.Variables() {
+		found := false
+		for _, pathVar := range config.Path {
+			if pathVar.Name == placeholder {
+				found = true
+				break
+			}
+		}
+
+		if found {
+			continue
+		}
+
+		if !config.HasConfig() {
+			mismatches = append(mismatches, diagnostics.Errorf(handlerFunc.Doc.Pos(), diagnostics.CodePathVariableMismatch, "handler %s has no config parameter to bind {%s}", handlerFunc.Name.Name, placeholder))
+		} else {
+			mismatches = append(mismatches, diagnostics.Errorf(handlerFunc.Doc.Pos(), diagnostics.CodePathVariableMismatch, "placeholder {%s} in path %s has no pathvar field in %s", placeholder, fullPath, config.Type.Obj().Name()))
+		}
+	}
+
+	for _, pathVar := range config.Path {
+		if !template.HasVariable(pathVar.Name) {
+			mismatches = append(mismatches, diagnostics.Errorf(pathVar.Pos, diagnostics.CodePathVariableMismatch, "pathvar field %s refers to '%s', but path %s has no {%s} placeholder", pathVar.FieldName, pathVar.Name, fullPath, pathVar.Name))
+		}
+	}
+
+	return errors.Join(mismatches...)
+}
+
+// report reports a diagnostic at a position in this parser's package
+func (parser *ServiceParser) report(pos token.Pos, severity diagnostics.Severity, code diagnostics.Code, format string, args ...any) {
+	parser.diags.Report(parser.pkg.Fset.Position(pos), severity, code, format, args...)
+}
+
+// reportError reports an error diagnostic in this parser's package, see diagnostics.Collector.ReportError
+func (parser *ServiceParser) reportError(fallbackPos token.Pos, fallbackCode diagnostics.Code, err error) {
+	parser.diags.ReportError(parser.pkg.Fset, fallbackPos, fallbackCode, err)
+}
+
+// withPos sets the position of a diagnostic error if it doesn't have one yet
+func withPos(err error, pos token.Pos) error {
+	var diagErr *diagnostics.Error
+	if errors.As(err, &diagErr) && !diagErr.Pos.IsValid() {
+		diagErr.Pos = pos
+	}
+
+	return err
 }
 
 func getVariableName(tagArgs string) string {
@@ -362,7 +784,7 @@ func getVariableName(tagArgs string) string {
 	return args[0]
 }
 
-func filterMethods(methods []*ast.FuncDecl) []*ast.FuncDecl {
+func filterMethods(methods []*ast.FuncDecl, roleType string) []*ast.FuncDecl {
 	var filtered []*ast.FuncDecl
 	for _, method := range methods {
 		if method.Doc == nil {
@@ -370,7 +792,7 @@ func filterMethods(methods []*ast.FuncDecl) []*ast.FuncDecl {
 		}
 
 		role, found := model.ParseObjectRoleDocstring(method.Doc.Text())
-		if !found || role.Type != model.ObjectRoleHandlerTp {
+		if !found || role.Type != roleType {
 			continue
 		}
 
@@ -0,0 +1,208 @@
+package parsing
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/softwaresale/lambdagen/internal/diagnostics"
+	"github.com/softwaresale/lambdagen/internal/model"
+)
+
+// parseTestdata parses the services in a package under testdata, along with the error diagnostics reported for them
+func parseTestdata(t *testing.T, name string) ([]model.ServiceDefinition, []diagnostics.Diagnostic) {
+	t.Helper()
+
+	diags := diagnostics.NewCollector()
+	services, err := ParseServices(".", "./testdata/"+name, diags)
+	if err != nil {
+		t.Fatalf("failed to load testdata/%s: %s", name, err)
+	}
+
+	var errs []diagnostics.Diagnostic
+	for _, diagnostic := range diags.Diagnostics() {
+		if diagnostic.Severity == diagnostics.SeverityError {
+			errs = append(errs, diagnostic)
+		}
+	}
+
+	return services, errs
+}
+
+// findDiagnostic finds the diagnostic whose message contains every given part
+func findDiagnostic(diags []diagnostics.Diagnostic, parts ...string) (diagnostics.Diagnostic, bool) {
+	for _, diagnostic := range diags {
+		found := true
+		for _, part := range parts {
+			found = found && strings.Contains(diagnostic.Message, part)
+		}
+
+		if found {
+			return diagnostic, true
+		}
+	}
+
+	return diagnostics.Diagnostic{}, false
+}
+
+// hasHandler checks if a service has a handler with the given method name
+func hasHandler(service model.ServiceDefinition, name string) bool {
+	for _, handler := range service.Handlers {
+		if handler.HandlerMethodName == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasConsumer checks if a service has a consumer with the given method name
+func hasConsumer(service model.ServiceDefinition, name string) bool {
+	for _, consumer := range service.Consumers {
+		if consumer.ConsumerMethodName == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestRequestVariableTypes(t *testing.T) {
+	tests := []struct {
+		handler string // handler is the handler whose config has the field
+		field   string // field is the config field under test
+		wantErr string // wantErr is part of the expected diagnostic, or empty if the field is accepted
+	}{
+		{handler: "Alias", field: "Alias"},
+		{handler: "NamedBasic", field: "NamedBasic"},
+		{handler: "Time", field: "Time"},
+		{handler: "Converted", field: "Converted"},
+		{handler: "Slice", field: "Slice"},
+		{handler: "Optional", field: "Optional"},
+		{handler: "StructLiteral", field: "StructLiteral", wantErr: "struct{A int} can't be converted from a string without a converter"},
+		{handler: "NamedStruct", field: "NamedStruct", wantErr: "variables.Box needs a converter or must implement encoding.TextUnmarshaler"},
+		{handler: "BoxSlice", field: "BoxSlice", wantErr: "variables.Box needs a converter or must implement encoding.TextUnmarshaler"},
+		{handler: "UnexportedType", field: "UnexportedType", wantErr: "variables.secret must be exported"},
+		{handler: "UnexportedField", field: "unexportedField", wantErr: "must be exported"},
+		{handler: "Complex", field: "Complex", wantErr: "complex128 can't be converted from a string"},
+		{handler: "PathSlice", field: "PathSlice", wantErr: "[]string can't be converted from a string without a converter"},
+		{handler: "HeaderSlice", field: "HeaderSlice"},
+		{handler: "ClaimSlice", field: "ClaimSlice", wantErr: "[]string can't be converted from a string without a converter"},
+		{handler: "NamedSlice", field: "NamedSlice"},
+		{handler: "ConvertedSlice", field: "ConvertedSlice"},
+		{handler: "PointerSlice", field: "PointerSlice", wantErr: "*[]int can't be bound, use []int instead"},
+	}
+
+	services, errs := parseTestdata(t, "variables")
+	if len(services) != 1 {
+		t.Fatalf("got %d services, want 1", len(services))
+	}
+
+	wantErrs := 0
+	for _, test := range tests {
+		t.Run(test.handler, func(t *testing.T) {
+			diagnostic, found := findDiagnostic(errs, fmt.Sprintf("field %s", test.field))
+			if len(test.wantErr) == 0 {
+				if found {
+					t.Fatalf("unexpected diagnostic: %s", diagnostic)
+				}
+
+				if !hasHandler(services[0], test.handler) {
+					t.Fatalf("handler %s was left out", test.handler)
+				}
+
+				return
+			}
+
+			wantErrs++
+			if !found || !strings.Contains(diagnostic.Message, test.wantErr) {
+				t.Fatalf("no diagnostic for field %s containing %q in %v", test.field, test.wantErr, errs)
+			}
+
+			if diagnostic.Code != diagnostics.CodeInvalidHandlerCfg {
+				t.Errorf("got code %s, want %s", diagnostic.Code, diagnostics.CodeInvalidHandlerCfg)
+			}
+
+			if !diagnostic.Pos.IsValid() {
+				t.Errorf("diagnostic has no position")
+			}
+
+			if hasHandler(services[0], test.handler) {
+				t.Errorf("handler %s was generated despite the diagnostic", test.handler)
+			}
+		})
+	}
+
+	if len(errs) != wantErrs {
+		t.Errorf("got %d diagnostics, want %d: %v", len(errs), wantErrs, errs)
+	}
+}
+
+func TestSignatureTypes(t *testing.T) {
+	tests := []struct {
+		method   string           // method is the handler or consumer under test
+		consumer bool             // consumer is set if the method is a consumer
+		wantCode diagnostics.Code // wantCode is the expected diagnostic code, or empty if the method is accepted
+		wantErr  string           // wantErr is part of the expected diagnostic
+	}{
+		{method: "AliasedConfig"},
+		{method: "UnexportedConfig", wantCode: diagnostics.CodeInvalidHandlerSignature, wantErr: "signatures.config of UnexportedConfig must be exported"},
+		{method: "GenericConfig", wantCode: diagnostics.CodeInvalidHandlerSignature, wantErr: "signatures.Page[int] of GenericConfig can't be generic"},
+		{method: "GenericResult"},
+		{method: "UnexportedResult", wantCode: diagnostics.CodeInvalidHandlerSignature, wantErr: "signatures.result must be exported"},
+		{method: "LiteralResult", wantCode: diagnostics.CodeInvalidHandlerSignature, wantErr: "struct{ID string} can't be used in generated code"},
+		{method: "ChanResult", wantCode: diagnostics.CodeInvalidHandlerSignature, wantErr: "chan int can't be used in generated code"},
+		{method: "JSONMessage", consumer: true},
+		{method: "UnexportedMessage", consumer: true, wantCode: diagnostics.CodeInvalidConsumerSig, wantErr: "signatures.message must be exported"},
+		{method: "ChanMessage", consumer: true, wantCode: diagnostics.CodeInvalidConsumerSig, wantErr: "chan int can't be decoded from JSON"},
+	}
+
+	services, errs := parseTestdata(t, "signatures")
+	if len(services) != 1 {
+		t.Fatalf("got %d services, want 1", len(services))
+	}
+
+	wantErrs := 0
+	for _, test := range tests {
+		t.Run(test.method, func(t *testing.T) {
+			generated := hasHandler(services[0], test.method)
+			if test.consumer {
+				generated = hasConsumer(services[0], test.method)
+			}
+
+			diagnostic, found := findDiagnostic(errs, test.method)
+			if len(test.wantCode) == 0 {
+				if found {
+					t.Fatalf("unexpected diagnostic: %s", diagnostic)
+				}
+
+				if !generated {
+					t.Fatalf("%s was left out", test.method)
+				}
+
+				return
+			}
+
+			wantErrs++
+			if !found || !strings.Contains(diagnostic.Message, test.wantErr) {
+				t.Fatalf("no diagnostic for %s containing %q in %v", test.method, test.wantErr, errs)
+			}
+
+			if diagnostic.Code != test.wantCode {
+				t.Errorf("got code %s, want %s", diagnostic.Code, test.wantCode)
+			}
+
+			if !diagnostic.Pos.IsValid() {
+				t.Errorf("diagnostic has no position")
+			}
+
+			if generated {
+				t.Errorf("%s was generated despite the diagnostic", test.method)
+			}
+		})
+	}
+
+	if len(errs) != wantErrs {
+		t.Errorf("got %d diagnostics, want %d: %v", len(errs), wantErrs, errs)
+	}
+}
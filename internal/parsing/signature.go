@@ -0,0 +1,337 @@
+package parsing
+
+import (
+	"errors"
+	"fmt"
+	"github.com/softwaresale/lambdagen/internal/diagnostics"
+	"github.com/softwaresale/lambdagen/internal/model"
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+// acceptedHandlerSignatures is included in signature diagnostics so users know what to write instead
+const acceptedHandlerSignatures = "handlers must have the signature func (s *Service) Name(ctx context.Context, cfg Config) (T, error) " +
+	"or func (s *Service) Name(ctx context.Context, cfg Config) error, where Config is a named struct type. The cfg parameter can be " +
+	"left out if the handler doesn't read anything from the request"
+
+// handlerSignature is the validated shape of a handler method
+type handlerSignature struct {
+	Config   *types.Named             // Config is the request config struct passed to the handler, or nil if there is none
+	Response model.ResponseDefinition // Response describes the value the handler responds with
+}
+
+// validateHandlerSignature checks that a handler method has a signature that lambdagen can call. Every violation is
+// returned as a separate diagnostic error.
+func (parser *ServiceParser) validateHandlerSignature(serviceObj types.Object, handlerFunc *ast.FuncDecl) (handlerSignature, error) {
+	handlerObj := parser.pkg.TypesInfo.ObjectOf(handlerFunc.Name)
+	signature := handlerObj.Type().(*types.Signature)
+
+	var violations []error
+	violation := func(pos token.Pos, format string, args ...any) {
+		if !pos.IsValid() {
+			pos = handlerFunc.Name.Pos()
+		}
+
+		message := fmt.Sprintf(format, args...)
+		violations = append(violations, diagnostics.Errorf(pos, diagnostics.CodeInvalidHandlerSignature, "%s\n\tnote: %s", message, acceptedHandlerSignatures))
+	}
+
+	validateMethodShape("handler", serviceObj, handlerFunc, signature, violation)
+
+	var handlerSig handlerSignature
+
+	// parameters
+	params := signature.Params()
+	if params.Len() < 1 || params.Len() > 2 {
+		violation(handlerFunc.Type.Params.Pos(), "handler %s has %d parameters, but must have 1 or 2", handlerFunc.Name.Name, params.Len())
+	}
+
+	if params.Len() > 0 && !isContextType(params.At(0).Type()) {
+		violation(params.At(0).Pos(), "first parameter of %s must be context.Context, but got %s", handlerFunc.Name.Name, params.At(0).Type().String())
+	}
+
+	// the config is optional
+	if params.Len() > 1 {
+		configParam := params.At(1)
+		configType, ok := types.Unalias(configParam.Type()).(*types.Named)
+		if ok {
+			_, ok = configType.Underlying().(*types.Struct)
+		}
+
+		// generated code builds the config with a composite literal of the named type
+		if !ok {
+			violation(configParam.Pos(), "second parameter of %s must be a named struct type, but got %s", handlerFunc.Name.Name, configParam.Type().String())
+		} else if !configType.Obj().Exported() {
+			violation(configParam.Pos(), "config %s of %s must be exported", configType.String(), handlerFunc.Name.Name)
+		} else if configType.TypeArgs().Len() > 0 {
+			violation(configParam.Pos(), "config %s of %s can't be generic", configType.String(), handlerFunc.Name.Name)
+		} else {
+			handlerSig.Config = configType
+		}
+	}
+
+	// results, which are either just an error or a value and an error
+	results := signature.Results()
+	if results.Len() == 1 {
+		handlerSig.Response = model.ResponseDefinition{Kind: model.ResponseKindNone}
+
+		if !isErrorType(results.At(0).Type()) {
+			violation(results.At(0).Pos(), "single result of %s must be error, but got %s", handlerFunc.Name.Name, results.At(0).Type().String())
+		}
+	} else if results.Len() != 2 {
+		violation(handlerFunc.Type.Pos(), "handler %s has %d results, but must have 1 or 2", handlerFunc.Name.Name, results.Len())
+	} else {
+		handlerSig.Response = responseDefinition(results.At(0).Type())
+
+		if err := validateTypeCode(results.At(0).Type()); err != nil {
+			violation(results.At(0).Pos(), "invalid result of %s: %s", handlerFunc.Name.Name, err)
+		}
+
+		if !isErrorType(results.At(1).Type()) {
+			violation(results.At(1).Pos(), "second result of %s must be error, but got %s", handlerFunc.Name.Name, results.At(1).Type().String())
+		}
+	}
+
+	if len(violations) > 0 {
+		return handlerSignature{}, errors.Join(violations...)
+	}
+
+	return handlerSig, nil
+}
+
+// acceptedConsumerSignatures is included in consumer signature diagnostics so users know what to write instead
+const acceptedConsumerSignatures = "consumers must have the signature func (s *Service) Name(ctx context.Context, msg T) error"
+
+// consumerSignature is the validated shape of a consumer method
+type consumerSignature struct {
+	MessageType types.Type            // MessageType is the type messages are decoded into
+	Encoding    model.MessageEncoding // Encoding is how message bodies are decoded
+}
+
+// validateConsumerSignature checks that a consumer method has a signature that lambdagen can call. Every violation is
+// returned as a separate diagnostic error.
+func (parser *ServiceParser) validateConsumerSignature(serviceObj types.Object, consumerFunc *ast.FuncDecl) (consumerSignature, error) {
+	consumerObj := parser.pkg.TypesInfo.ObjectOf(consumerFunc.Name)
+	signature := consumerObj.Type().(*types.Signature)
+
+	var violations []error
+	violation := func(pos token.Pos, format string, args ...any) {
+		if !pos.IsValid() {
+			pos = consumerFunc.Name.Pos()
+		}
+
+		message := fmt.Sprintf(format, args...)
+		violations = append(violations, diagnostics.Errorf(pos, diagnostics.CodeInvalidConsumerSig, "%s\n\tnote: %s", message, acceptedConsumerSignatures))
+	}
+
+	validateMethodShape("consumer", serviceObj, consumerFunc, signature, violation)
+
+	var consumerSig consumerSignature
+
+	params := signature.Params()
+	if params.Len() != 2 {
+		violation(consumerFunc.Type.Params.Pos(), "consumer %s has %d parameters, but must have 2", consumerFunc.Name.Name, params.Len())
+	} else {
+		if !isContextType(params.At(0).Type()) {
+			violation(params.At(0).Pos(), "first parameter of %s must be context.Context, but got %s", consumerFunc.Name.Name, params.At(0).Type().String())
+		}
+
+		consumerSig.MessageType = params.At(1).Type()
+		consumerSig.Encoding = messageEncoding(consumerSig.MessageType)
+
+		// strings and []byte always pass, everything else is decoded from JSON
+		if err := validateBodyType(consumerSig.MessageType); err != nil {
+			violation(params.At(1).Pos(), "invalid message of %s: %s", consumerFunc.Name.Name, err)
+		}
+	}
+
+	results := signature.Results()
+	if results.Len() != 1 || !isErrorType(results.At(0).Type()) {
+		violation(consumerFunc.Type.Pos(), "consumer %s must return only an error", consumerFunc.Name.Name)
+	}
+
+	if len(violations) > 0 {
+		return consumerSignature{}, errors.Join(violations...)
+	}
+
+	return consumerSig, nil
+}
+
+// messageEncoding works out how a message body is decoded. Strings and []byte take the raw body, and everything else is
+// JSON. Like response bodies, named string and byte slice types are still JSON.
+func messageEncoding(messageType types.Type) model.MessageEncoding {
+	if basic, ok := messageType.(*types.Basic); ok && basic.Kind() == types.String {
+		return model.MessageEncodingString
+	}
+
+	if slice, ok := messageType.(*types.Slice); ok {
+		if elem, ok := slice.Elem().(*types.Basic); ok && elem.Kind() == types.Byte {
+			return model.MessageEncodingBytes
+		}
+	}
+
+	return model.MessageEncodingJSON
+}
+
+// responseDefinition works out how the value returned by a handler is sent
+func responseDefinition(responseType types.Type) model.ResponseDefinition {
+	// wrappers can also be returned by pointer
+	wrapperType, pointer := responseType, false
+	if ptrType, ok := responseType.(*types.Pointer); ok {
+		wrapperType, pointer = ptrType.Elem(), true
+	}
+
+	if named, ok := wrapperType.(*types.Named); ok && isRuntimeType(named, "Response") && named.TypeArgs().Len() == 1 {
+		bodyType := named.TypeArgs().At(0)
+		return model.ResponseDefinition{
+			Kind:     model.ResponseKindWrapped,
+			Type:     responseType,
+			BodyType: bodyType,
+			Encoding: bodyEncoding(bodyType),
+			Pointer:  pointer,
+		}
+	}
+
+	return model.ResponseDefinition{
+		Kind:     model.ResponseKindJSON,
+		Type:     responseType,
+		BodyType: responseType,
+		Encoding: bodyEncoding(responseType),
+	}
+}
+
+// ioReaderType is io.Reader
+var ioReaderType = types.NewInterfaceType([]*types.Func{
+	types.NewFunc(token.NoPos, nil, "Read", types.NewSignatureType(nil, nil, nil,
+		types.NewTuple(types.NewParam(token.NoPos, nil, "p", types.NewSlice(types.Typ[types.Byte]))),
+		types.NewTuple(
+			types.NewParam(token.NoPos, nil, "n", types.Typ[types.Int]),
+			types.NewParam(token.NoPos, nil, "err", types.Universe.Lookup("error").Type()),
+		),
+		false,
+	)),
+}, nil).Complete()
+
+// bodyEncoding works out how a response body is encoded. []byte, readers, and pkg.FileResponse are sent as binary, and
+// everything else is JSON. Named byte slices like json.RawMessage are still JSON.
+func bodyEncoding(bodyType types.Type) model.BodyEncoding {
+	if slice, ok := bodyType.(*types.Slice); ok {
+		if elem, ok := slice.Elem().(*types.Basic); ok && elem.Kind() == types.Byte {
+			return model.BodyEncodingBytes
+		}
+	}
+
+	if named, ok := bodyType.(*types.Named); ok && isRuntimeType(named, "FileResponse") {
+		return model.BodyEncodingFile
+	}
+
+	if types.Implements(bodyType, ioReaderType) {
+		return model.BodyEncodingReader
+	}
+
+	return model.BodyEncodingJSON
+}
+
+// validateMethodShape checks the parts of a handler or consumer signature that don't depend on its kind. kind names
+// the method in violations.
+func validateMethodShape(kind string, serviceObj types.Object, methodFunc *ast.FuncDecl, signature *types.Signature, violation func(pos token.Pos, format string, args ...any)) {
+	// receiver must be the service itself, promoted methods could be called through a nil embedded pointer
+	receiverType := signature.Recv().Type()
+	if ptrType, ok := receiverType.(*types.Pointer); ok {
+		receiverType = ptrType.Elem()
+	}
+
+	if !types.Identical(receiverType, serviceObj.Type()) {
+		violation(methodFunc.Recv.Pos(), "%s %s is declared on %s, but must be declared on service %s", kind, methodFunc.Name.Name, receiverType.String(), serviceObj.Name())
+	}
+
+	if signature.Variadic() {
+		violation(methodFunc.Type.Params.Pos(), "%s %s cannot be variadic", kind, methodFunc.Name.Name)
+	}
+}
+
+// validateTypeCode checks that a type can be written in generated code, which lives in another package. It accepts
+// the same types as codegen.TypeCode.
+func validateTypeCode(tp types.Type) error {
+	switch tp := tp.(type) {
+	case *types.Basic:
+		if tp.Kind() == types.UnsafePointer || tp.Info()&types.IsUntyped != 0 {
+			return fmt.Errorf("%s can't be used in generated code", tp.String())
+		}
+
+		return nil
+
+	case *types.Named:
+		if tp.Obj().Pkg() != nil && !tp.Obj().Exported() {
+			return fmt.Errorf("%s must be exported", tp.String())
+		}
+
+		for i := range tp.TypeArgs().Len() {
+			if err := validateTypeCode(tp.TypeArgs().At(i)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+
+	case *types.Alias:
+		if tp.Obj().Pkg() != nil && !tp.Obj().Exported() {
+			return fmt.Errorf("%s must be exported", tp.String())
+		}
+
+		// aliases are written by name, which would drop any type arguments
+		if tp.TypeArgs().Len() > 0 {
+			return fmt.Errorf("generic alias %s can't be used in generated code", tp.String())
+		}
+
+		return nil
+
+	case *types.Interface:
+		if !tp.Empty() {
+			return fmt.Errorf("interface literal %s can't be used in generated code, declare a named interface instead", tp.String())
+		}
+
+		return nil
+
+	case *types.Pointer:
+		return validateTypeCode(tp.Elem())
+
+	case *types.Slice:
+		return validateTypeCode(tp.Elem())
+
+	case *types.Array:
+		return validateTypeCode(tp.Elem())
+
+	case *types.Map:
+		if err := validateTypeCode(tp.Key()); err != nil {
+			return err
+		}
+
+		return validateTypeCode(tp.Elem())
+
+	default:
+		return fmt.Errorf("%s can't be used in generated code, declare a named type instead", tp.String())
+	}
+}
+
+// isRuntimeType checks if a named type is the given type from the lambdagen runtime package
+func isRuntimeType(named *types.Named, name string) bool {
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == "github.com/softwaresale/lambdagen/pkg" && obj.Name() == name
+}
+
+// isContextType checks if tp is context.Context
+func isContextType(tp types.Type) bool {
+	named, ok := tp.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return false
+	}
+
+	return named.Obj().Pkg().Path() == "context" && named.Obj().Name() == "Context"
+}
+
+// isErrorType checks if tp is the builtin error type
+func isErrorType(tp types.Type) bool {
+	return types.Identical(tp, types.Universe.Lookup("error").Type())
+}
@@ -0,0 +1,87 @@
+package signatures
+
+import (
+	"context"
+
+	"github.com/softwaresale/lambdagen/pkg"
+)
+
+// lambdagen:service
+type SignatureService struct{}
+
+// lambdagen:service_init
+func NewSignatureService() (*SignatureService, error) {
+	return &SignatureService{}, nil
+}
+
+type Config struct {
+	ID string `lambdagen:"queryvar"`
+}
+
+type ConfigAlias = Config
+
+type config struct {
+	ID string `lambdagen:"queryvar"`
+}
+
+type Page[T any] struct {
+	Items []T
+}
+
+type result struct {
+	ID string
+}
+
+type message struct {
+	ID string
+}
+
+// lambdagen:handler :: GET /aliased-config
+func (s *SignatureService) AliasedConfig(ctx context.Context, cfg ConfigAlias) error {
+	return nil
+}
+
+// lambdagen:handler :: GET /unexported-config
+func (s *SignatureService) UnexportedConfig(ctx context.Context, cfg config) error {
+	return nil
+}
+
+// lambdagen:handler :: GET /generic-config
+func (s *SignatureService) GenericConfig(ctx context.Context, cfg Page[int]) error {
+	return nil
+}
+
+// lambdagen:handler :: GET /generic-result
+func (s *SignatureService) GenericResult(ctx context.Context) (*pkg.Response[Page[Config]], error) {
+	return nil, nil
+}
+
+// lambdagen:handler :: GET /unexported-result
+func (s *SignatureService) UnexportedResult(ctx context.Context) (*result, error) {
+	return nil, nil
+}
+
+// lambdagen:handler :: GET /literal-result
+func (s *SignatureService) LiteralResult(ctx context.Context) (struct{ ID string }, error) {
+	return struct{ ID string }{}, nil
+}
+
+// lambdagen:handler :: GET /chan-result
+func (s *SignatureService) ChanResult(ctx context.Context) (chan int, error) {
+	return nil, nil
+}
+
+// lambdagen:consumer :: sqs
+func (s *SignatureService) JSONMessage(ctx context.Context, msg Config) error {
+	return nil
+}
+
+// lambdagen:consumer :: sqs
+func (s *SignatureService) UnexportedMessage(ctx context.Context, msg message) error {
+	return nil
+}
+
+// lambdagen:consumer :: sqs
+func (s *SignatureService) ChanMessage(ctx context.Context, msg chan int) error {
+	return nil
+}
@@ -0,0 +1,202 @@
+package variables
+
+import (
+	"context"
+	"time"
+)
+
+// lambdagen:service
+type VariableService struct{}
+
+// lambdagen:service_init
+func NewVariableService() (*VariableService, error) {
+	return &VariableService{}, nil
+}
+
+type OrderID = string
+
+type Celsius float64
+
+// Point has a converter
+type Point struct{ X, Y int }
+
+// lambdagen:converter
+func ParsePoint(raw string) (Point, error) {
+	return Point{}, nil
+}
+
+// Box has neither a converter nor UnmarshalText
+type Box struct{ Width, Height int }
+
+type secret struct{ value string }
+
+func (s *secret) UnmarshalText(text []byte) error {
+	s.value = string(text)
+	return nil
+}
+
+type AliasConfig struct {
+	Alias OrderID `lambdagen:"pathvar"`
+}
+
+// lambdagen:handler :: GET /alias/{alias}
+func (s *VariableService) Alias(ctx context.Context, cfg AliasConfig) error {
+	return nil
+}
+
+type NamedBasicConfig struct {
+	NamedBasic Celsius `lambdagen:"queryvar"`
+}
+
+// lambdagen:handler :: GET /named-basic
+func (s *VariableService) NamedBasic(ctx context.Context, cfg NamedBasicConfig) error {
+	return nil
+}
+
+type TimeConfig struct {
+	Time time.Time `lambdagen:"header,If-Modified-Since"`
+}
+
+// lambdagen:handler :: GET /time
+func (s *VariableService) Time(ctx context.Context, cfg TimeConfig) error {
+	return nil
+}
+
+type ConvertedConfig struct {
+	Converted Point `lambdagen:"queryvar"`
+}
+
+// lambdagen:handler :: GET /converted
+func (s *VariableService) Converted(ctx context.Context, cfg ConvertedConfig) error {
+	return nil
+}
+
+type SliceConfig struct {
+	Slice []*int `lambdagen:"queryvar"`
+}
+
+// lambdagen:handler :: GET /slice
+func (s *VariableService) Slice(ctx context.Context, cfg SliceConfig) error {
+	return nil
+}
+
+type OptionalConfig struct {
+	Optional *OrderID `lambdagen:"claim,sub"`
+}
+
+// lambdagen:handler :: GET /optional
+func (s *VariableService) Optional(ctx context.Context, cfg OptionalConfig) error {
+	return nil
+}
+
+type StructLiteralConfig struct {
+	StructLiteral struct{ A int } `lambdagen:"queryvar"`
+}
+
+// lambdagen:handler :: GET /struct-literal
+func (s *VariableService) StructLiteral(ctx context.Context, cfg StructLiteralConfig) error {
+	return nil
+}
+
+type NamedStructConfig struct {
+	NamedStruct Box `lambdagen:"header,X-Box"`
+}
+
+// lambdagen:handler :: GET /named-struct
+func (s *VariableService) NamedStruct(ctx context.Context, cfg NamedStructConfig) error {
+	return nil
+}
+
+type BoxSliceConfig struct {
+	BoxSlice []Box `lambdagen:"queryvar"`
+}
+
+// lambdagen:handler :: GET /box-slice
+func (s *VariableService) BoxSlice(ctx context.Context, cfg BoxSliceConfig) error {
+	return nil
+}
+
+type UnexportedTypeConfig struct {
+	UnexportedType secret `lambdagen:"queryvar"`
+}
+
+// lambdagen:handler :: GET /unexported-type
+func (s *VariableService) UnexportedType(ctx context.Context, cfg UnexportedTypeConfig) error {
+	return nil
+}
+
+type UnexportedFieldConfig struct {
+	unexportedField string `lambdagen:"queryvar"`
+}
+
+// lambdagen:handler :: GET /unexported-field
+func (s *VariableService) UnexportedField(ctx context.Context, cfg UnexportedFieldConfig) error {
+	return nil
+}
+
+type ComplexConfig struct {
+	Complex complex128 `lambdagen:"queryvar"`
+}
+
+// lambdagen:handler :: GET /complex
+func (s *VariableService) Complex(ctx context.Context, cfg ComplexConfig) error {
+	return nil
+}
+
+type PathSliceConfig struct {
+	PathSlice []string `lambdagen:"pathvar"`
+}
+
+// lambdagen:handler :: GET /path-slice/{pathSlice}
+func (s *VariableService) PathSlice(ctx context.Context, cfg PathSliceConfig) error {
+	return nil
+}
+
+type HeaderSliceConfig struct {
+	HeaderSlice []Celsius `lambdagen:"header,X-Temperature"`
+}
+
+// lambdagen:handler :: GET /header-slice
+func (s *VariableService) HeaderSlice(ctx context.Context, cfg HeaderSliceConfig) error {
+	return nil
+}
+
+type ClaimSliceConfig struct {
+	ClaimSlice []string `lambdagen:"claim,groups"`
+}
+
+// lambdagen:handler :: GET /claim-slice
+func (s *VariableService) ClaimSlice(ctx context.Context, cfg ClaimSliceConfig) error {
+	return nil
+}
+
+type Temperatures []Celsius
+
+type NamedSliceConfig struct {
+	NamedSlice Temperatures `lambdagen:"queryvar"`
+}
+
+// lambdagen:handler :: GET /named-slice
+func (s *VariableService) NamedSlice(ctx context.Context, cfg NamedSliceConfig) error {
+	return nil
+}
+
+type Points []Point
+
+type ConvertedSliceConfig struct {
+	ConvertedSlice Points `lambdagen:"header,X-Point"`
+}
+
+// lambdagen:handler :: GET /converted-slice
+func (s *VariableService) ConvertedSlice(ctx context.Context, cfg ConvertedSliceConfig) error {
+	return nil
+}
+
+type PointerSliceConfig struct {
+	PointerSlice *[]int `lambdagen:"queryvar"`
+}
+
+// lambdagen:handler :: GET /pointer-slice
+func (s *VariableService) PointerSlice(ctx context.Context, cfg PointerSliceConfig) error {
+	return nil
+}
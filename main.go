@@ -3,6 +3,8 @@ package main
 import (
 	"flag"
 	"fmt"
+	"github.com/softwaresale/lambdagen/internal/diagnostics"
+	"github.com/softwaresale/lambdagen/internal/model"
 	"github.com/softwaresale/lambdagen/internal/output"
 	"github.com/softwaresale/lambdagen/internal/parsing"
 	"log"
@@ -13,6 +15,9 @@ type Args struct {
 	RootModuleDir string
 	Modules       []string
 	OutputModName string
+	Target        string
+	Packaging     string
+	Verbose       bool
 }
 
 var args Args
@@ -20,6 +25,9 @@ var args Args
 func init() {
 	flag.StringVar(&args.RootModuleDir, "project", "", "root directory of project to generate lambdas for")
 	flag.StringVar(&args.OutputModName, "output", "lambda", "directory to store lambdas in")
+	flag.StringVar(&args.Target, "target", string(model.DefaultTarget), "event source to generate handlers for, unless a service sets target=")
+	flag.StringVar(&args.Packaging, "packaging", string(model.DefaultPackaging), "how handlers are grouped into lambdas: handler, service, or monolith, unless a service sets packaging=")
+	flag.BoolVar(&args.Verbose, "verbose", false, "also print informational diagnostics, like the services that were found")
 }
 
 func main() {
@@ -40,42 +48,104 @@ func main() {
 		log.Fatal("no handler modules provided")
 	}
 
+	defaultTarget, err := model.ParseTarget(args.Target)
+	if err != nil {
+		log.Fatalf("invalid -target: %s", err)
+	}
+
+	defaultPackaging, err := model.ParsePackaging(args.Packaging)
+	if err != nil {
+		log.Fatalf("invalid -packaging: %s", err)
+	}
+
+	diags := diagnostics.NewCollector()
+
+	// every module shares an output, so that a monolith can serve all of them
+	outputManager := output.NewManager(args.RootModuleDir, args.OutputModName)
+
+	failed := false
 	for _, module := range args.Modules {
-		err = createHandlersForModule(module)
+		err = createHandlersForModule(module, defaultTarget, defaultPackaging, outputManager, diags)
 		if err != nil {
 			log.Println(err)
+			failed = true
+		}
+	}
+
+	// don't generate anything if a module has problems, the lambdas would be missing handlers
+	if !failed && !diags.HasErrors() {
+		err = renderLambdas(outputManager, diags)
+		if err != nil {
+			log.Println(err)
+			failed = true
 		}
 	}
-}
 
-func createHandlersForModule(mod string) error {
-	services, err := parsing.ParseServices(args.RootModuleDir, mod)
+	err = diags.Print(os.Stderr, args.Verbose)
 	if err != nil {
-		return fmt.Errorf("while parsing module %s:\n%w", mod, err)
+		log.Fatalf("while printing diagnostics: %s", err)
 	}
 
-	// lambda output directory
-	outputManager := output.NewManager(args.RootModuleDir, args.OutputModName)
+	if failed || diags.HasErrors() {
+		os.Exit(1)
+	}
+}
 
-	err = outputManager.CreateOutputDir()
+func createHandlersForModule(mod string, defaultTarget model.Target, defaultPackaging model.Packaging, outputManager *output.Manager, diags *diagnostics.Collector) error {
+	previousErrors := diags.ErrorCount()
+
+	services, err := parsing.ParseServices(args.RootModuleDir, mod, diags)
 	if err != nil {
-		return fmt.Errorf("while creating base output directory: %w", err)
+		return fmt.Errorf("while parsing module %s:\n%w", mod, err)
+	}
+
+	// don't register anything for a module with problems
+	if diags.ErrorCount() > previousErrors {
+		return nil
 	}
 
 	for _, service := range services {
+		if len(service.Target) == 0 {
+			service.Target = defaultTarget
+		}
+
+		if len(service.Packaging) == 0 {
+			service.Packaging = defaultPackaging
+		}
+
+		// report every conflict instead of stopping at the first
 		for _, handler := range service.Handlers {
 
 			err = outputManager.Register(&service, &handler)
 			if err != nil {
-				return fmt.Errorf("while registering handler for module %s:\n%w", mod, err)
+				diags.ReportError(service.Pkg.Fset, handler.Pos, diagnostics.CodeRouteConflict, err)
 			}
 
 		}
+
+		for _, consumer := range service.Consumers {
+
+			err = outputManager.RegisterConsumer(&service, &consumer)
+			if err != nil {
+				diags.ReportError(service.Pkg.Fset, consumer.Pos, diagnostics.CodeServiceConflict, err)
+			}
+
+		}
+	}
+
+	return nil
+}
+
+func renderLambdas(outputManager *output.Manager, diags *diagnostics.Collector) error {
+	// lambda output directory
+	err := outputManager.CreateOutputDir()
+	if err != nil {
+		return fmt.Errorf("while creating base output directory: %w", err)
 	}
 
-	err = outputManager.Render()
+	err = outputManager.Render(diags)
 	if err != nil {
-		return fmt.Errorf("while rendering lambdas for module %s:\n%w", mod, err)
+		return fmt.Errorf("while rendering lambdas:\n%w", err)
 	}
 
 	return nil
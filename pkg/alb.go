@@ -0,0 +1,57 @@
+package pkg
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ALBRequestQuery merges the query parameters of a load balancer request. Unlike API Gateway, load balancers pass
+// keys and values through URL encoded, so they are decoded here. Values that fail to decode are kept as is.
+func ALBRequestQuery(params map[string]string, multiValueParams map[string][]string) url.Values {
+	merged := RequestQuery(params, multiValueParams)
+
+	decoded := make(url.Values, len(merged))
+	for key, values := range merged {
+		decodedKey := queryUnescape(key)
+		for _, value := range values {
+			decoded.Add(decodedKey, queryUnescape(value))
+		}
+	}
+
+	return decoded
+}
+
+func queryUnescape(value string) string {
+	unescaped, err := url.QueryUnescape(value)
+	if err != nil {
+		return value
+	}
+
+	return unescaped
+}
+
+// ALBTraceID gets the X-Amzn-Trace-Id header that load balancers add to requests. Load balancer events have no request
+// ID, so this identifies requests in error bodies instead.
+func ALBTraceID(headers map[string]string, multiValueHeaders map[string][]string) string {
+	return RequestHeaders(headers, multiValueHeaders).Get("X-Amzn-Trace-Id")
+}
+
+// ALBSingleValueHeaders merges headers for a target group without multi-value headers. Each header can only have one
+// value, so repeated headers are joined with commas and only the first cookie is set.
+func ALBSingleValueHeaders(headers http.Header, cookies []*http.Cookie, defaultContentType string) map[string]string {
+	merged := SingleValueHeaders(headers, defaultContentType)
+	if len(cookies) > 0 {
+		merged["Set-Cookie"] = cookies[0].String()
+	} else if cookie := headers.Get("Set-Cookie"); len(cookie) > 0 {
+		merged["Set-Cookie"] = cookie
+	}
+
+	return merged
+}
+
+// StatusDescription formats a status for the statusDescription of a load balancer response, i.e. "404 Not Found"
+func StatusDescription(status int) string {
+	return strings.TrimSpace(fmt.Sprintf("%d %s", status, http.StatusText(status)))
+}
@@ -1,7 +1,71 @@
 package pkg
 
+import (
+	"time"
+)
+
+// Error codes used by generated code. Handlers can use their own codes through HTTPError.
+const (
+	ErrorCodeMissingParameter = "missing_parameter" // a required request variable was not provided
+	ErrorCodeInvalidParameter = "invalid_parameter" // a request variable could not be converted
+	ErrorCodeInvalidBody      = "invalid_body"      // the request body could not be decoded
+	ErrorCodeUnsupportedMedia = "unsupported_media" // the request body has a content type the handler can't decode
+	ErrorCodeFileTooLarge     = "file_too_large"    // an uploaded file is larger than its max_size
+	ErrorCodeInternal         = "internal_error"    // the handler failed, or the response could not be encoded
+)
+
+// ExposeErrorCauses controls whether the text of internal errors is sent to clients in APIError.Cause. It is off by
+// default, since internal errors can leak implementation details. Services turn it on with expose_errors=true.
+var ExposeErrorCauses = false
+
 // APIError describes an API error body that can be returned
 type APIError struct {
+	Code      string       `json:"code"`                // Code is a machine-readable error code, i.e. invalid_parameter
+	Message   string       `json:"message"`             // Message is a human-readable description of the error
+	RequestID string       `json:"requestId,omitempty"` // RequestID identifies the failed request in logs
+	Fields    []FieldError `json:"fields,omitempty"`    // Fields describes validation failures of individual fields
+	Details   any          `json:"details,omitempty"`   // Details is any extra information provided by the handler
+	Cause     string       `json:"cause,omitempty"`     // Cause is the internal error text, if ExposeErrorCauses is set
+	Timestamp time.Time    `json:"timestamp"`           // Timestamp is when the error happened
+}
+
+// FieldError describes why a single request field was rejected
+type FieldError struct {
+	Field   string `json:"field"`
 	Message string `json:"message"`
-	Error   error  `json:"error"`
+}
+
+// NewAPIError creates an error body. The cause is only included if ExposeErrorCauses is set.
+func NewAPIError(code, message, requestID string, cause error) APIError {
+	apiErr := APIError{
+		Code:      code,
+		Message:   message,
+		RequestID: requestID,
+		Timestamp: time.Now().UTC(),
+	}
+
+	if ExposeErrorCauses && cause != nil {
+		apiErr.Cause = cause.Error()
+	}
+
+	return apiErr
+}
+
+// NewFieldAPIError creates an error body for a single rejected request field
+func NewFieldAPIError(code, field, message, requestID string, cause error) APIError {
+	apiErr := NewAPIError(code, message, requestID, cause)
+	apiErr.Fields = []FieldError{
+		{Field: field, Message: message},
+	}
+
+	return apiErr
+}
+
+// APIError creates the error body for this error
+func (err *HTTPError) APIError(requestID string) APIError {
+	apiErr := NewAPIError(err.Code, err.Message, requestID, err.Err)
+	apiErr.Fields = err.Fields
+	apiErr.Details = err.Details
+
+	return apiErr
 }
@@ -0,0 +1,91 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ErrEarlierItemFailed fails the items of an ordered batch that come after a failed item
+var ErrEarlierItemFailed = errors.New("not processed because an earlier item failed")
+
+// BatchItemError is an item of a batch that failed to process
+type BatchItemError struct {
+	Index int   // Index is the position of the item in the batch
+	Err   error // Err is the error the item failed with
+}
+
+// ProcessBatch calls process for every item of a batch, running at most concurrency items at once. Items that return
+// an error or panic are reported as failures, ordered by index. Use ProcessOrderedBatch for FIFO queues.
+func ProcessBatch(ctx context.Context, size, concurrency int, process func(ctx context.Context, idx int) error) []BatchItemError {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	errs := make([]error, size)
+	if concurrency == 1 {
+		for idx := range size {
+			errs[idx] = processItem(ctx, idx, process)
+		}
+	} else {
+		var wg sync.WaitGroup
+		slots := make(chan struct{}, concurrency)
+		for idx := range size {
+			slots <- struct{}{}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-slots }()
+				errs[idx] = processItem(ctx, idx, process)
+			}()
+		}
+
+		wg.Wait()
+	}
+
+	var failures []BatchItemError
+	for idx, err := range errs {
+		if err != nil {
+			failures = append(failures, BatchItemError{Index: idx, Err: err})
+		}
+	}
+
+	return failures
+}
+
+// ProcessOrderedBatch calls process for every item of a batch in order. It stops at the first item that fails, and
+// reports that item and every item after it as failures. FIFO queues need this to keep message groups in order.
+func ProcessOrderedBatch(ctx context.Context, size int, process func(ctx context.Context, idx int) error) []BatchItemError {
+	var failures []BatchItemError
+	for idx := range size {
+		if len(failures) > 0 {
+			failures = append(failures, BatchItemError{Index: idx, Err: ErrEarlierItemFailed})
+			continue
+		}
+
+		err := processItem(ctx, idx, process)
+		if err != nil {
+			failures = append(failures, BatchItemError{Index: idx, Err: err})
+		}
+	}
+
+	return failures
+}
+
+// IsFIFOQueue checks if a queue ARN names a FIFO queue, whose names always end in .fifo
+func IsFIFOQueue(queueARN string) bool {
+	return strings.HasSuffix(queueARN, ".fifo")
+}
+
+// processItem processes a single item, turning a panic into an error so that one item can't fail the whole batch
+func processItem(ctx context.Context, idx int, process func(ctx context.Context, idx int) error) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic while processing item %d: %v", idx, recovered)
+		}
+	}()
+
+	return process(ctx, idx)
+}
@@ -0,0 +1,185 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+var errItem = errors.New("item failed")
+
+// batchItem is how a test item behaves when processed
+type batchItem int
+
+const (
+	itemOK batchItem = iota
+	itemError
+	itemPanic
+)
+
+func processItems(items []batchItem, processed *[]int, mutex *sync.Mutex) func(ctx context.Context, idx int) error {
+	return func(ctx context.Context, idx int) error {
+		mutex.Lock()
+		*processed = append(*processed, idx)
+		mutex.Unlock()
+
+		switch items[idx] {
+		case itemError:
+			return errItem
+		case itemPanic:
+			panic("boom")
+		default:
+			return nil
+		}
+	}
+}
+
+func failedIndexes(failures []BatchItemError) []int {
+	var indexes []int
+	for _, failure := range failures {
+		indexes = append(indexes, failure.Index)
+	}
+
+	return indexes
+}
+
+func TestProcessBatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		items       []batchItem
+		concurrency int
+		wantFailed  []int
+	}{
+		{"empty", nil, 1, nil},
+		{"all succeed", []batchItem{itemOK, itemOK, itemOK}, 1, nil},
+		{"errors", []batchItem{itemOK, itemError, itemOK, itemError}, 1, []int{1, 3}},
+		{"panics", []batchItem{itemPanic, itemOK}, 1, []int{0}},
+		{"concurrent", []batchItem{itemError, itemOK, itemPanic, itemOK, itemError}, 3, []int{0, 2, 4}},
+		{"concurrency below one", []batchItem{itemOK, itemError}, 0, []int{1}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var processed []int
+			var mutex sync.Mutex
+
+			failures := ProcessBatch(context.Background(), len(test.items), test.concurrency, processItems(test.items, &processed, &mutex))
+			if got := failedIndexes(failures); !slices.Equal(got, test.wantFailed) {
+				t.Errorf("failed items = %v, want %v", got, test.wantFailed)
+			}
+
+			// every item is processed, even after a failure
+			slices.Sort(processed)
+			if len(processed) != len(test.items) {
+				t.Errorf("processed items = %v, want all %d", processed, len(test.items))
+			}
+
+			for _, failure := range failures {
+				if failure.Err == nil {
+					t.Errorf("item %d failed without an error", failure.Index)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessBatchConcurrencyLimit(t *testing.T) {
+	const size, concurrency = 6, 2
+
+	var running, maxRunning atomic.Int32
+	release := make(chan struct{})
+	started := make(chan struct{}, size)
+
+	done := make(chan []BatchItemError)
+	go func() {
+		done <- ProcessBatch(context.Background(), size, concurrency, func(ctx context.Context, idx int) error {
+			current := running.Add(1)
+			for {
+				previous := maxRunning.Load()
+				if current <= previous || maxRunning.CompareAndSwap(previous, current) {
+					break
+				}
+			}
+
+			started <- struct{}{}
+			<-release
+			running.Add(-1)
+			return nil
+		})
+	}()
+
+	// fill every slot before letting any item finish
+	for range concurrency {
+		<-started
+	}
+
+	close(release)
+	if failures := <-done; len(failures) != 0 {
+		t.Errorf("unexpected failures: %v", failures)
+	}
+
+	if got := maxRunning.Load(); got != concurrency {
+		t.Errorf("ran %d items at once, want %d", got, concurrency)
+	}
+}
+
+func TestProcessOrderedBatch(t *testing.T) {
+	tests := []struct {
+		name          string
+		items         []batchItem
+		wantProcessed []int
+		wantFailed    []int
+	}{
+		{"empty", nil, nil, nil},
+		{"all succeed", []batchItem{itemOK, itemOK, itemOK}, []int{0, 1, 2}, nil},
+		{"stops at error", []batchItem{itemOK, itemError, itemOK, itemOK}, []int{0, 1}, []int{1, 2, 3}},
+		{"stops at panic", []batchItem{itemPanic, itemOK}, []int{0}, []int{0, 1}},
+		{"last item fails", []batchItem{itemOK, itemOK, itemError}, []int{0, 1, 2}, []int{2}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var processed []int
+			var mutex sync.Mutex
+
+			failures := ProcessOrderedBatch(context.Background(), len(test.items), processItems(test.items, &processed, &mutex))
+			if !slices.Equal(processed, test.wantProcessed) {
+				t.Errorf("processed items = %v, want %v", processed, test.wantProcessed)
+			}
+
+			if got := failedIndexes(failures); !slices.Equal(got, test.wantFailed) {
+				t.Errorf("failed items = %v, want %v", got, test.wantFailed)
+			}
+
+			// only the first failure is the item's own error
+			for idx, failure := range failures {
+				if isSkipped := errors.Is(failure.Err, ErrEarlierItemFailed); isSkipped != (idx > 0) {
+					t.Errorf("item %d failed with %v", failure.Index, failure.Err)
+				}
+			}
+		})
+	}
+}
+
+func TestIsFIFOQueue(t *testing.T) {
+	tests := []struct {
+		arn  string
+		want bool
+	}{
+		{"arn:aws:sqs:us-east-1:123456789012:orders.fifo", true},
+		{"arn:aws:sqs:us-east-1:123456789012:orders", false},
+		{"arn:aws:sqs:us-east-1:123456789012:fifo-orders", false},
+		{"", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.arn, func(t *testing.T) {
+			if got := IsFIFOQueue(test.arn); got != test.want {
+				t.Errorf("IsFIFOQueue(%q) = %v, want %v", test.arn, got, test.want)
+			}
+		})
+	}
+}
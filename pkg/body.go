@@ -0,0 +1,240 @@
+package pkg
+
+import (
+	"encoding"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// content types of the request and response bodies that generated code decodes and encodes
+const (
+	ContentTypeForm        = "application/x-www-form-urlencoded"
+	ContentTypeText        = "text/plain"
+	ContentTypeMultipart   = "multipart/form-data"
+	ContentTypeOctetStream = "application/octet-stream"
+)
+
+// RequestBody gets the raw bytes of a request body. API Gateway base64 encodes binary payloads, so those are decoded
+// first.
+func RequestBody(body string, isBase64Encoded bool) ([]byte, error) {
+	if !isBase64Encoded {
+		return []byte(body), nil
+	}
+
+	return base64.StdEncoding.DecodeString(body)
+}
+
+// MediaType gets the media type of the Content-Type header without any parameters, i.e. application/json for
+// "application/json; charset=utf-8". Returns empty if there is no Content-Type. Malformed headers are returned as is,
+// so they won't match any supported type.
+func MediaType(headers http.Header) string {
+	contentType := headers.Get("Content-Type")
+	if len(contentType) == 0 {
+		return ""
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return contentType
+	}
+
+	return mediaType
+}
+
+// textUnmarshalerType is used to check if form fields can decode themselves
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// DecodeForm decodes an application/x-www-form-urlencoded body into target, which must be a pointer to a struct or to
+// a map with string keys. Struct fields are matched by their form tag, then their json tag, then their name. Slice
+// fields take every value of a repeated key, and anything else takes the first.
+func DecodeForm(body []byte, target any) error {
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return err
+	}
+
+	targetValue := reflect.ValueOf(target)
+	if targetValue.Kind() != reflect.Pointer || targetValue.IsNil() {
+		return fmt.Errorf("form target must be a non-nil pointer, but got %T", target)
+	}
+
+	return decodeFormValues(values, targetValue.Elem())
+}
+
+// decodeFormValues decodes values into a struct or map, allocating pointers along the way
+func decodeFormValues(values url.Values, target reflect.Value) error {
+	switch target.Kind() {
+	case reflect.Pointer:
+		if target.IsNil() {
+			target.Set(reflect.New(target.Type().Elem()))
+		}
+
+		return decodeFormValues(values, target.Elem())
+
+	case reflect.Struct:
+		targetType := target.Type()
+		for i := range targetType.NumField() {
+			field := targetType.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			name := formFieldName(field)
+			if name == "-" {
+				continue
+			}
+
+			fieldValues := values[name]
+			if len(fieldValues) == 0 {
+				continue
+			}
+
+			if err := setFormField(target.Field(i), fieldValues); err != nil {
+				return fmt.Errorf("field %s: %w", name, err)
+			}
+		}
+
+		return nil
+
+	case reflect.Map:
+		if target.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("form maps must have string keys, but got %s", target.Type())
+		}
+
+		if target.IsNil() {
+			target.Set(reflect.MakeMapWithSize(target.Type(), len(values)))
+		}
+
+		for key, keyValues := range values {
+			elem := reflect.New(target.Type().Elem()).Elem()
+			if err := setFormField(elem, keyValues); err != nil {
+				return fmt.Errorf("field %s: %w", key, err)
+			}
+
+			target.SetMapIndex(reflect.ValueOf(key).Convert(target.Type().Key()), elem)
+		}
+
+		return nil
+
+	default:
+		return fmt.Errorf("can't decode a form into %s", target.Type())
+	}
+}
+
+// formFieldName gets the form key of a struct field
+func formFieldName(field reflect.StructField) string {
+	for _, tagKey := range []string{"form", "json"} {
+		tag, ok := field.Tag.Lookup(tagKey)
+		if !ok {
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+		if len(name) > 0 {
+			return name
+		}
+	}
+
+	return field.Name
+}
+
+// setFormField sets a field from all values of its key
+func setFormField(field reflect.Value, values []string) error {
+	switch field.Kind() {
+	case reflect.Pointer:
+		value := reflect.New(field.Type().Elem())
+		if err := setFormField(value.Elem(), values); err != nil {
+			return err
+		}
+
+		field.Set(value)
+		return nil
+
+	case reflect.Slice:
+		if field.Type().Elem().Kind() == reflect.Uint8 {
+			// []byte takes the raw value
+			break
+		}
+
+		slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setFormValue(slice.Index(i), value); err != nil {
+				return err
+			}
+		}
+
+		field.Set(slice)
+		return nil
+	}
+
+	return setFormValue(field, values[0])
+}
+
+// setFormValue converts a single form value into a field
+func setFormValue(field reflect.Value, value string) error {
+	if field.CanAddr() && field.Addr().Type().Implements(textUnmarshalerType) {
+		return field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+
+		field.SetBool(parsed)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		field.SetInt(parsed)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		field.SetUint(parsed)
+
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		field.SetFloat(parsed)
+
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("unsupported form field type %s", field.Type())
+		}
+
+		field.SetBytes([]byte(value))
+
+	case reflect.Interface:
+		if field.NumMethod() > 0 {
+			return fmt.Errorf("unsupported form field type %s", field.Type())
+		}
+
+		field.Set(reflect.ValueOf(value))
+
+	default:
+		return fmt.Errorf("unsupported form field type %s", field.Type())
+	}
+
+	return nil
+}
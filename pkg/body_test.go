@@ -0,0 +1,118 @@
+package pkg
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type formTarget struct {
+	Name     string    `form:"name"`
+	Email    string    `json:"email,omitempty"`
+	Age      int       // Age is matched by its field name
+	Score    float64   `form:"score"`
+	Active   bool      `form:"active"`
+	Count    uint8     `form:"count"`
+	Tags     []string  `form:"tag"`
+	IDs      []int     `form:"id"`
+	Nickname *string   `form:"nickname"`
+	Raw      []byte    `form:"raw"`
+	Since    time.Time `form:"since"`
+	Skipped  string    `form:"-"`
+	hidden   string
+}
+
+func TestDecodeForm(t *testing.T) {
+	nickname := "al"
+	since := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		body    string
+		want    formTarget
+		wantErr bool
+	}{
+		{name: "empty", body: "", want: formTarget{}},
+		{name: "form tag", body: "name=Ada+Lovelace", want: formTarget{Name: "Ada Lovelace"}},
+		{name: "json tag", body: "email=ada%40example.com", want: formTarget{Email: "ada@example.com"}},
+		{name: "field name", body: "Age=36", want: formTarget{Age: 36}},
+		{name: "scalars", body: "score=9.5&active=true&count=200", want: formTarget{Score: 9.5, Active: true, Count: 200}},
+		{name: "first value wins", body: "name=a&name=b", want: formTarget{Name: "a"}},
+		{name: "repeated keys", body: "tag=a&tag=b&id=1&id=2", want: formTarget{Tags: []string{"a", "b"}, IDs: []int{1, 2}}},
+		{name: "pointer", body: "nickname=al", want: formTarget{Nickname: &nickname}},
+		{name: "bytes", body: "raw=abc", want: formTarget{Raw: []byte("abc")}},
+		{name: "text unmarshaler", body: "since=2024-05-01T12:00:00Z", want: formTarget{Since: since}},
+		{name: "skipped fields", body: "Skipped=x&hidden=y&unknown=z", want: formTarget{}},
+		{name: "invalid int", body: "Age=old", wantErr: true},
+		{name: "int overflow", body: "count=300", wantErr: true},
+		{name: "invalid bool", body: "active=maybe", wantErr: true},
+		{name: "invalid slice item", body: "id=1&id=two", wantErr: true},
+		{name: "invalid text", body: "since=yesterday", wantErr: true},
+		{name: "malformed body", body: "name=%zz", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got formTarget
+			err := DecodeForm([]byte(test.body), &got)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("DecodeForm(%q) = %+v, want an error", test.body, got)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("DecodeForm(%q) unexpected error: %v", test.body, err)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("DecodeForm(%q) = %+v, want %+v", test.body, got, test.want)
+			}
+		})
+	}
+}
+
+func TestDecodeFormTargets(t *testing.T) {
+	t.Run("map", func(t *testing.T) {
+		var got map[string][]string
+		if err := DecodeForm([]byte("a=1&a=2&b=3"), &got); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		want := map[string][]string{"a": {"1", "2"}, "b": {"3"}}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("pointer to pointer", func(t *testing.T) {
+		var got *formTarget
+		if err := DecodeForm([]byte("name=ada"), &got); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got == nil || got.Name != "ada" {
+			t.Errorf("got %+v, want name ada", got)
+		}
+	})
+
+	invalidTargets := []struct {
+		name   string
+		target any
+	}{
+		{"not a pointer", formTarget{}},
+		{"nil pointer", (*formTarget)(nil)},
+		{"map without string keys", &map[int]string{}},
+		{"scalar", new(string)},
+	}
+
+	for _, test := range invalidTargets {
+		t.Run(test.name, func(t *testing.T) {
+			if err := DecodeForm([]byte("a=1"), test.target); err == nil {
+				t.Errorf("DecodeForm into %T succeeded, want an error", test.target)
+			}
+		})
+	}
+}
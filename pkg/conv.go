@@ -2,4 +2,7 @@ package pkg
 
 // RequestVarConvert is an interface for converting string request variables into custom user types.
 // These custom user types should still be based on literals.
+//
+// Converters are registered either by annotating a package-level function with lambdagen:converter, in which case it is
+// used for every request variable of its result type, or by naming it in a field tag, i.e. converter=ParseOrderID.
 type RequestVarConvert[ResT any] func(rawVal string) (ResT, error)
@@ -0,0 +1,95 @@
+package pkg
+
+import (
+	"encoding/base64"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+)
+
+// FileResponse is a binary response body with its own content type. Handlers return it, or a Response[FileResponse],
+// to serve files like images and exports.
+type FileResponse struct {
+	ContentType string // ContentType is the content type of the file. Empty means application/octet-stream
+	Filename    string // Filename, if set, makes clients download the file under this name
+	Content     []byte // Content is the file contents
+}
+
+// NewFileResponse creates a file response with the given content type
+func NewFileResponse(contentType string, content []byte) FileResponse {
+	return FileResponse{
+		ContentType: contentType,
+		Content:     content,
+	}
+}
+
+// AsAttachment makes clients download the file under the given name
+func (file FileResponse) AsAttachment(filename string) FileResponse {
+	file.Filename = filename
+	return file
+}
+
+// Header adds the Content-Type and Content-Disposition headers of the file to the given headers. Headers that were
+// already set are kept.
+func (file FileResponse) Header(headers http.Header) http.Header {
+	merged := headers.Clone()
+	if merged == nil {
+		merged = make(http.Header, 2)
+	}
+
+	if len(merged.Values("Content-Type")) == 0 {
+		contentType := file.ContentType
+		if len(contentType) == 0 {
+			contentType = ContentTypeOctetStream
+		}
+
+		merged.Set("Content-Type", contentType)
+	}
+
+	if len(file.Filename) > 0 && len(merged.Values("Content-Disposition")) == 0 {
+		merged.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": file.Filename}))
+	}
+
+	return merged
+}
+
+// HeaderValues gets the headers of the file as multi-value headers
+func (file FileResponse) HeaderValues() map[string][]string {
+	return MultiValueHeaders(file.Header(nil), nil, "")
+}
+
+// EncodeBinaryBody base64 encodes a binary response body. Responses with IsBase64Encoded set are decoded back into
+// binary before being sent to clients.
+func EncodeBinaryBody(content []byte) string {
+	return base64.StdEncoding.EncodeToString(content)
+}
+
+// ReadResponseBody reads a response body from a reader. The reader is closed afterwards if it's an io.Closer. A nil
+// reader is an empty body, including a nil pointer returned as an io.Reader.
+func ReadResponseBody(reader io.Reader) ([]byte, error) {
+	if isNilReader(reader) {
+		return nil, nil
+	}
+
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	return io.ReadAll(reader)
+}
+
+// isNilReader checks if a reader is nil, or is an interface holding a nil pointer, map, slice, chan, or func
+func isNilReader(reader io.Reader) bool {
+	if reader == nil {
+		return true
+	}
+
+	value := reflect.ValueOf(reader)
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func, reflect.Interface:
+		return value.IsNil()
+	default:
+		return false
+	}
+}
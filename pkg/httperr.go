@@ -0,0 +1,106 @@
+package pkg
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// HTTPError is an error that maps to an HTTP status code. Handlers can return these, or wrap them, to respond with
+// something other than an internal server error.
+type HTTPError struct {
+	Status  int          // Status is the HTTP status code to respond with
+	Code    string       // Code is a machine-readable error code, i.e. not_found
+	Message string       // Message is a human-readable description of the error
+	Fields  []FieldError // Fields describes validation failures of individual fields
+	Details any          // Details is any extra information to include in the response
+	Err     error        // Err is an optional underlying cause. It is only sent to clients if ExposeErrorCauses is set
+}
+
+// NewHTTPError creates an error with the given status and code
+func NewHTTPError(status int, code string, format string, args ...any) *HTTPError {
+	return &HTTPError{
+		Status:  status,
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+func (err *HTTPError) Error() string {
+	if err.Err != nil {
+		return fmt.Sprintf("%d %s: %s: %s", err.Status, err.Code, err.Message, err.Err)
+	}
+
+	return fmt.Sprintf("%d %s: %s", err.Status, err.Code, err.Message)
+}
+
+// StatusCode gets the status to respond with. Errors built without a valid status, i.e. as struct literals without
+// Status, respond with 500 Internal Server Error.
+func (err *HTTPError) StatusCode() int {
+	if err.Status < 100 || err.Status > 599 {
+		return http.StatusInternalServerError
+	}
+
+	return err.Status
+}
+
+func (err *HTTPError) Unwrap() error {
+	return err.Err
+}
+
+// WithDetails attaches extra information to the error
+func (err *HTTPError) WithDetails(details any) *HTTPError {
+	err.Details = details
+	return err
+}
+
+// WithFields attaches field-level validation failures to the error
+func (err *HTTPError) WithFields(fields ...FieldError) *HTTPError {
+	err.Fields = append(err.Fields, fields...)
+	return err
+}
+
+// Wrap attaches an underlying cause to the error
+func (err *HTTPError) Wrap(cause error) *HTTPError {
+	err.Err = cause
+	return err
+}
+
+func BadRequest(format string, args ...any) *HTTPError {
+	return NewHTTPError(http.StatusBadRequest, "bad_request", format, args...)
+}
+
+func Unauthorized(format string, args ...any) *HTTPError {
+	return NewHTTPError(http.StatusUnauthorized, "unauthorized", format, args...)
+}
+
+func Forbidden(format string, args ...any) *HTTPError {
+	return NewHTTPError(http.StatusForbidden, "forbidden", format, args...)
+}
+
+func NotFound(format string, args ...any) *HTTPError {
+	return NewHTTPError(http.StatusNotFound, "not_found", format, args...)
+}
+
+func MethodNotAllowed(format string, args ...any) *HTTPError {
+	return NewHTTPError(http.StatusMethodNotAllowed, "method_not_allowed", format, args...)
+}
+
+func Conflict(format string, args ...any) *HTTPError {
+	return NewHTTPError(http.StatusConflict, "conflict", format, args...)
+}
+
+func PreconditionFailed(format string, args ...any) *HTTPError {
+	return NewHTTPError(http.StatusPreconditionFailed, "precondition_failed", format, args...)
+}
+
+func UnprocessableEntity(format string, args ...any) *HTTPError {
+	return NewHTTPError(http.StatusUnprocessableEntity, "unprocessable_entity", format, args...)
+}
+
+func TooManyRequests(format string, args ...any) *HTTPError {
+	return NewHTTPError(http.StatusTooManyRequests, "too_many_requests", format, args...)
+}
+
+func ServiceUnavailable(format string, args ...any) *HTTPError {
+	return NewHTTPError(http.StatusServiceUnavailable, "service_unavailable", format, args...)
+}
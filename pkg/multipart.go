@@ -0,0 +1,93 @@
+package pkg
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+)
+
+// UploadedFile is a file uploaded in a multipart/form-data body. Handlers bind to it with lambdagen:"file,name".
+type UploadedFile struct {
+	Filename    string // Filename is the name the client gave the file
+	ContentType string // ContentType is the content type of the part, if the client sent one
+	Content     []byte // Content is the file contents
+}
+
+// Size gets the size of the file in bytes
+func (file UploadedFile) Size() int64 {
+	return int64(len(file.Content))
+}
+
+// Reader gets a reader over the file contents
+func (file UploadedFile) Reader() *bytes.Reader {
+	return bytes.NewReader(file.Content)
+}
+
+// MultipartForm is a decoded multipart/form-data body
+type MultipartForm struct {
+	Values url.Values                // Values are the parts without a filename
+	Files  map[string][]UploadedFile // Files are the parts with a filename
+}
+
+// ParseMultipartForm decodes a multipart/form-data body. The boundary is taken from the Content-Type header.
+func ParseMultipartForm(headers http.Header, body []byte) (*MultipartForm, error) {
+	_, params, err := mime.ParseMediaType(headers.Get("Content-Type"))
+	if err != nil {
+		return nil, err
+	}
+
+	boundary, ok := params["boundary"]
+	if !ok {
+		return nil, errors.New("multipart body has no boundary")
+	}
+
+	form := &MultipartForm{
+		Values: make(url.Values),
+		Files:  make(map[string][]UploadedFile),
+	}
+
+	reader := multipart.NewReader(bytes.NewReader(body), boundary)
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := io.ReadAll(part)
+		if err != nil {
+			return nil, fmt.Errorf("part %s: %w", part.FormName(), err)
+		}
+
+		if len(part.FileName()) == 0 {
+			form.Values.Add(part.FormName(), string(content))
+			continue
+		}
+
+		form.Files[part.FormName()] = append(form.Files[part.FormName()], UploadedFile{
+			Filename:    part.FileName(),
+			ContentType: part.Header.Get("Content-Type"),
+			Content:     content,
+		})
+	}
+
+	return form, nil
+}
+
+// LookupFile finds the first file uploaded under the given name. If there is none, then return empty and false.
+func (form *MultipartForm) LookupFile(name string) (UploadedFile, bool) {
+	files := form.Files[name]
+	if len(files) == 0 {
+		return UploadedFile{}, false
+	}
+
+	return files[0], true
+}
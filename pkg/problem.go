@@ -0,0 +1,74 @@
+package pkg
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ErrorFormat selects how generated code encodes error responses
+type ErrorFormat string
+
+const (
+	ErrorFormatAPIError ErrorFormat = "api"     // ErrorFormatAPIError encodes errors as an APIError
+	ErrorFormatProblem  ErrorFormat = "problem" // ErrorFormatProblem encodes errors as an RFC 7807 ProblemDetails
+)
+
+const (
+	ContentTypeJSON    = "application/json"
+	ContentTypeProblem = "application/problem+json"
+)
+
+// ActiveErrorFormat is the format error responses are encoded in. Services select it with errors=problem.
+var ActiveErrorFormat = ErrorFormatAPIError
+
+// ProblemTypeBase is prepended to error codes to build the problem type URI, i.e. https://errors.example.com/ gives
+// https://errors.example.com/not_found. If empty, the type is about:blank. Services set it with problem_types=<uri>.
+var ProblemTypeBase = ""
+
+// ProblemDetails is an RFC 7807 problem document. Everything past Instance is an extension member carried over from
+// the APIError.
+type ProblemDetails struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code,omitempty"`
+	RequestID string       `json:"requestId,omitempty"`
+	Fields    []FieldError `json:"fields,omitempty"`
+	Details   any          `json:"details,omitempty"`
+	Cause     string       `json:"cause,omitempty"`
+}
+
+// Problem converts this error into a problem document. instance identifies where the problem happened, usually the
+// request path.
+func (apiErr APIError) Problem(status int, instance string) ProblemDetails {
+	problemType := "about:blank"
+	if len(ProblemTypeBase) > 0 {
+		problemType = ProblemTypeBase + apiErr.Code
+	}
+
+	return ProblemDetails{
+		Type:      problemType,
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    apiErr.Message,
+		Instance:  instance,
+		Code:      apiErr.Code,
+		RequestID: apiErr.RequestID,
+		Fields:    apiErr.Fields,
+		Details:   apiErr.Details,
+		Cause:     apiErr.Cause,
+	}
+}
+
+// MarshalAPIError encodes an error response body in the active error format, and gets its content type
+func MarshalAPIError(status int, apiErr APIError, instance string) ([]byte, string, error) {
+	if ActiveErrorFormat == ErrorFormatProblem {
+		body, err := json.Marshal(apiErr.Problem(status, instance))
+		return body, ContentTypeProblem, err
+	}
+
+	body, err := json.Marshal(apiErr)
+	return body, ContentTypeJSON, err
+}
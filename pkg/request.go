@@ -0,0 +1,115 @@
+package pkg
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// RequestHeaders merges the single and multi-value header maps of a request into a single http.Header. Keys are
+// canonicalized, so lookups on the result are case-insensitive.
+func RequestHeaders(headers map[string]string, multiValueHeaders map[string][]string) http.Header {
+	merged := make(http.Header, len(headers))
+	for key, values := range multiValueHeaders {
+		for _, value := range values {
+			merged.Add(key, value)
+		}
+	}
+
+	for key, value := range headers {
+		if len(merged.Values(key)) == 0 {
+			merged.Set(key, value)
+		}
+	}
+
+	return merged
+}
+
+// LookupHeader finds the first value of the given header. If the header is not present, then return empty and false.
+func LookupHeader(headers http.Header, name string) (string, bool) {
+	values := headers.Values(name)
+	if len(values) == 0 {
+		return "", false
+	}
+
+	return values[0], true
+}
+
+// RequestQuery merges the single and multi-value query parameter maps of a request into a single url.Values. Repeated
+// keys are taken from the multi-value map when present.
+func RequestQuery(params map[string]string, multiValueParams map[string][]string) url.Values {
+	merged := make(url.Values, len(params))
+	for key, values := range multiValueParams {
+		merged[key] = append(merged[key], values...)
+	}
+
+	for key, value := range params {
+		if _, ok := merged[key]; !ok {
+			merged.Set(key, value)
+		}
+	}
+
+	return merged
+}
+
+// LookupQuery finds the first value of the given query parameter. If the parameter is not present, then return empty
+// and false.
+func LookupQuery(query url.Values, name string) (string, bool) {
+	values, ok := query[name]
+	if !ok || len(values) == 0 {
+		return "", false
+	}
+
+	return values[0], true
+}
+
+// SplitValues splits each comma-separated value into separate values. Empty entries are dropped.
+func SplitValues(values []string) []string {
+	var split []string
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			if len(part) == 0 {
+				continue
+			}
+
+			split = append(split, part)
+		}
+	}
+
+	return split
+}
+
+// ParseRawQuery parses the raw query string of an HTTP API request. Repeated keys keep every value, unlike the
+// comma-joined QueryStringParameters. Malformed pairs are skipped.
+func ParseRawQuery(rawQuery string) url.Values {
+	values, _ := url.ParseQuery(rawQuery)
+	return values
+}
+
+// HTTPAPIRequestHeaders converts the headers of an HTTP API request into an http.Header. HTTP APIs move cookies out
+// of the headers, so they are joined back into a Cookie header.
+func HTTPAPIRequestHeaders(headers map[string]string, cookies []string) http.Header {
+	merged := RequestHeaders(headers, nil)
+	if len(cookies) > 0 && len(merged.Values("Cookie")) == 0 {
+		merged.Set("Cookie", strings.Join(cookies, "; "))
+	}
+
+	return merged
+}
+
+// RESTAuthorizerClaims gets the claims of a REST API authorizer, i.e. a Cognito user pool authorizer. Claims are
+// formatted as strings so that they can be converted like any other request variable.
+func RESTAuthorizerClaims(authorizer map[string]any) map[string]string {
+	claims, ok := authorizer["claims"].(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	formatted := make(map[string]string, len(claims))
+	for key, value := range claims {
+		formatted[key] = fmt.Sprint(value)
+	}
+
+	return formatted
+}
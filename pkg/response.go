@@ -0,0 +1,132 @@
+package pkg
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Response lets a handler control the status code, headers, and cookies it responds with. Handlers return a
+// Response[T] instead of T, and Body is encoded just like a plain T would be.
+type Response[T any] struct {
+	Status  int            // Status is the status code to respond with. Zero means 200 OK
+	Headers http.Header    // Headers are additional response headers
+	Cookies []*http.Cookie // Cookies are set with Set-Cookie headers
+	Body    T              // Body is the response body
+}
+
+// NewResponse creates a response with the given status and body
+func NewResponse[T any](status int, body T) Response[T] {
+	return Response[T]{
+		Status: status,
+		Body:   body,
+	}
+}
+
+// OK creates a 200 response
+func OK[T any](body T) Response[T] {
+	return NewResponse(http.StatusOK, body)
+}
+
+// Created creates a 201 response with a Location header pointing at the new resource
+func Created[T any](location string, body T) Response[T] {
+	return NewResponse(http.StatusCreated, body).WithHeader("Location", location)
+}
+
+// WithHeader adds a response header
+func (response Response[T]) WithHeader(key, value string) Response[T] {
+	if response.Headers == nil {
+		response.Headers = make(http.Header)
+	} else {
+		response.Headers = response.Headers.Clone()
+	}
+
+	response.Headers.Add(key, value)
+	return response
+}
+
+// WithCookie adds a cookie to set
+func (response Response[T]) WithCookie(cookie *http.Cookie) Response[T] {
+	response.Cookies = append(response.Cookies[:len(response.Cookies):len(response.Cookies)], cookie)
+	return response
+}
+
+// StatusCode gets the status to respond with, defaulting to 200 OK
+func (response Response[T]) StatusCode() int {
+	return response.StatusCodeOr(http.StatusOK)
+}
+
+// StatusCodeOr gets the status to respond with, or the given default if the handler didn't set one
+func (response Response[T]) StatusCodeOr(defaultStatus int) int {
+	if response.Status == 0 {
+		return defaultStatus
+	}
+
+	return response.Status
+}
+
+// HasBody checks if the status allows a response body
+func (response Response[T]) HasBody() bool {
+	return StatusHasBody(response.StatusCode())
+}
+
+// StatusHasBody checks if a status allows a response body. 1xx, 204, and 304 responses never have one.
+func StatusHasBody(status int) bool {
+	return status != http.StatusNoContent && status != http.StatusNotModified && status >= http.StatusOK
+}
+
+// HeaderValues merges the headers and cookies into multi-value headers. The content type is used if the handler did
+// not set one.
+func (response Response[T]) HeaderValues(defaultContentType string) map[string][]string {
+	return MultiValueHeaders(response.Headers, response.Cookies, defaultContentType)
+}
+
+// MultiValueHeaders merges headers and cookies into a multi-value header map. The content type is used if the headers
+// don't have one. Cookies become Set-Cookie headers.
+func MultiValueHeaders(headers http.Header, cookies []*http.Cookie, defaultContentType string) map[string][]string {
+	merged := make(http.Header, len(headers)+2)
+	for key, values := range headers {
+		for _, value := range values {
+			merged.Add(key, value)
+		}
+	}
+
+	if len(merged.Values("Content-Type")) == 0 && len(defaultContentType) > 0 {
+		merged.Set("Content-Type", defaultContentType)
+	}
+
+	for _, cookie := range cookies {
+		merged.Add("Set-Cookie", cookie.String())
+	}
+
+	return merged
+}
+
+// SingleValueHeaders merges headers into a single-value header map, joining repeated headers with commas. The content
+// type is used if the headers don't have one. HTTP APIs send cookies separately, so Set-Cookie headers are left out.
+func SingleValueHeaders(headers http.Header, defaultContentType string) map[string]string {
+	merged := make(map[string]string, len(headers)+1)
+	for key, values := range headers {
+		key = http.CanonicalHeaderKey(key)
+		if key == "Set-Cookie" || len(values) == 0 {
+			continue
+		}
+
+		merged[key] = strings.Join(values, ",")
+	}
+
+	if _, ok := merged["Content-Type"]; !ok && len(defaultContentType) > 0 {
+		merged["Content-Type"] = defaultContentType
+	}
+
+	return merged
+}
+
+// CookieValues formats cookies as Set-Cookie values
+func CookieValues(cookies []*http.Cookie) []string {
+	values := make([]string, 0, len(cookies))
+	for _, cookie := range cookies {
+		values = append(values, cookie.String())
+	}
+
+	return values
+}
@@ -0,0 +1,155 @@
+package pkg
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Route maps methods and a path template to a handler. Generated lambdas that serve more than one handler dispatch
+// requests through a table of routes.
+type Route[H any] struct {
+	Methods []string // Methods are the HTTP methods the route responds to. ANY matches every method
+	Path    string   // Path is the path template, i.e. /orders/{orderId}
+	Handler H        // Handler is called for requests that match the route
+}
+
+// MatchRoute finds the route for a request and extracts its path parameters. When several paths match, the most
+// specific one wins: literal segments beat placeholders, which beat greedy placeholders. Returns a NotFound error if no
+// path matches, and a MethodNotAllowed error if a path matches but not with the request method.
+func MatchRoute[H any](routes []Route[H], method, path string) (Route[H], map[string]string, error) {
+	var best *Route[H]
+	var bestParams map[string]string
+	pathMatched := false
+
+	for idx := range routes {
+		route := &routes[idx]
+		params := MatchPath(route.Path, path)
+		if params == nil {
+			continue
+		}
+
+		pathMatched = true
+		if !routeAllowsMethod(route.Methods, method) {
+			continue
+		}
+
+		if best == nil || moreSpecificPath(route.Path, best.Path) {
+			best = route
+			bestParams = params
+		}
+	}
+
+	if best != nil {
+		return *best, bestParams, nil
+	}
+
+	var empty Route[H]
+	if pathMatched {
+		return empty, nil, MethodNotAllowed("method %s is not allowed for %s", method, path)
+	}
+
+	return empty, nil, NotFound("no route for %s", path)
+}
+
+// StripStage removes the stage prefix from the raw path of an HTTP API request. HTTP APIs only leave it out for the
+// $default stage.
+func StripStage(rawPath, stage string) string {
+	if len(stage) == 0 || stage == "$default" {
+		return rawPath
+	}
+
+	prefix := "/" + stage
+	if rawPath == prefix {
+		return "/"
+	}
+
+	if strings.HasPrefix(rawPath, prefix+"/") {
+		return strings.TrimPrefix(rawPath, prefix)
+	}
+
+	return rawPath
+}
+
+func routeAllowsMethod(methods []string, method string) bool {
+	for _, allowed := range methods {
+		if allowed == "ANY" || strings.EqualFold(allowed, method) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// moreSpecificPath checks if the path template left is more specific than right
+func moreSpecificPath(left, right string) bool {
+	leftSegments := strings.Split(strings.Trim(left, "/"), "/")
+	rightSegments := strings.Split(strings.Trim(right, "/"), "/")
+
+	for idx := 0; idx < len(leftSegments) && idx < len(rightSegments); idx++ {
+		leftKind, rightKind := segmentKind(leftSegments[idx]), segmentKind(rightSegments[idx])
+		if leftKind != rightKind {
+			return leftKind < rightKind
+		}
+	}
+
+	return len(leftSegments) > len(rightSegments)
+}
+
+// segmentKind ranks path template segments by specificity, lowest first
+func segmentKind(segment string) int {
+	switch {
+	case strings.HasSuffix(segment, "+}"):
+		return 2
+	case strings.HasPrefix(segment, "{"):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// MatchPath extracts the placeholder values of a path template, i.e. /orders/{orderId} matched against /orders/12
+// gives orderId=12. Greedy {name+} placeholders take the rest of the path. Returns nil if the path doesn't match.
+func MatchPath(template, path string) map[string]string {
+	templateSegments := strings.Split(strings.Trim(template, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+
+	params := make(map[string]string)
+	for idx, segment := range templateSegments {
+		isVariable := strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+		name := strings.TrimSuffix(strings.Trim(segment, "{}"), "+")
+
+		if isVariable && strings.HasSuffix(segment, "+}") {
+			if idx >= len(pathSegments) {
+				return nil
+			}
+
+			params[name] = pathUnescape(strings.Join(pathSegments[idx:], "/"))
+			return params
+		}
+
+		if idx >= len(pathSegments) {
+			return nil
+		}
+
+		if isVariable {
+			params[name] = pathUnescape(pathSegments[idx])
+		} else if segment != pathSegments[idx] {
+			return nil
+		}
+	}
+
+	if len(pathSegments) != len(templateSegments) {
+		return nil
+	}
+
+	return params
+}
+
+func pathUnescape(value string) string {
+	unescaped, err := url.PathUnescape(value)
+	if err != nil {
+		return value
+	}
+
+	return unescaped
+}
@@ -0,0 +1,122 @@
+package pkg
+
+import (
+	"errors"
+	"maps"
+	"net/http"
+	"testing"
+)
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		path     string
+		want     map[string]string
+	}{
+		{"literal", "/orders", "/orders", map[string]string{}},
+		{"root", "/", "/", map[string]string{}},
+		{"trailing slash", "/orders", "/orders/", map[string]string{}},
+		{"placeholder", "/orders/{orderId}", "/orders/12", map[string]string{"orderId": "12"}},
+		{"several placeholders", "/users/{userId}/orders/{orderId}", "/users/4/orders/12", map[string]string{"userId": "4", "orderId": "12"}},
+		{"escaped placeholder", "/files/{name}", "/files/a%20b", map[string]string{"name": "a b"}},
+		{"malformed escape", "/files/{name}", "/files/a%zz", map[string]string{"name": "a%zz"}},
+		{"greedy placeholder", "/files/{path+}", "/files/a/b/c", map[string]string{"path": "a/b/c"}},
+		{"greedy needs a segment", "/files/{path+}", "/files", nil},
+		{"literal mismatch", "/orders", "/users", nil},
+		{"too short", "/orders/{orderId}", "/orders", nil},
+		{"too long", "/orders/{orderId}", "/orders/12/items", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := MatchPath(test.template, test.path)
+			if (got == nil) != (test.want == nil) || !maps.Equal(got, test.want) {
+				t.Errorf("MatchPath(%q, %q) = %v, want %v", test.template, test.path, got, test.want)
+			}
+		})
+	}
+}
+
+func TestMatchRoute(t *testing.T) {
+	routes := []Route[string]{
+		{Methods: []string{"GET"}, Path: "/orders/{orderId}", Handler: "getOrder"},
+		{Methods: []string{"GET"}, Path: "/orders/latest", Handler: "latestOrder"},
+		{Methods: []string{"DELETE"}, Path: "/orders/{orderId}", Handler: "deleteOrder"},
+		{Methods: []string{"GET"}, Path: "/files/{path+}", Handler: "getFile"},
+		{Methods: []string{"GET"}, Path: "/files/{dir}/index", Handler: "getIndex"},
+		{Methods: []string{"ANY"}, Path: "/health", Handler: "health"},
+		{Methods: []string{"POST"}, Path: "/orders", Handler: "createOrder"},
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantRoute  string
+		wantParams map[string]string
+		wantStatus int
+	}{
+		{"placeholder", "GET", "/orders/12", "getOrder", map[string]string{"orderId": "12"}, 0},
+		{"literal beats placeholder", "GET", "/orders/latest", "latestOrder", map[string]string{}, 0},
+		{"method picks route", "DELETE", "/orders/12", "deleteOrder", map[string]string{"orderId": "12"}, 0},
+		{"method is case insensitive", "delete", "/orders/12", "deleteOrder", map[string]string{"orderId": "12"}, 0},
+		{"placeholder beats greedy", "GET", "/files/docs/index", "getIndex", map[string]string{"dir": "docs"}, 0},
+		{"greedy", "GET", "/files/docs/a/b", "getFile", map[string]string{"path": "docs/a/b"}, 0},
+		{"any method", "PATCH", "/health", "health", map[string]string{}, 0},
+		{"not found", "GET", "/users", "", nil, http.StatusNotFound},
+		{"method not allowed", "PUT", "/orders/12", "", nil, http.StatusMethodNotAllowed},
+		{"method not allowed for literal", "GET", "/orders", "", nil, http.StatusMethodNotAllowed},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			route, params, err := MatchRoute(routes, test.method, test.path)
+			if test.wantStatus != 0 {
+				var httpErr *HTTPError
+				if !errors.As(err, &httpErr) || httpErr.StatusCode() != test.wantStatus {
+					t.Fatalf("MatchRoute(%s %s) error = %v, want status %d", test.method, test.path, err, test.wantStatus)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("MatchRoute(%s %s) unexpected error: %v", test.method, test.path, err)
+			}
+
+			if route.Handler != test.wantRoute {
+				t.Errorf("MatchRoute(%s %s) route = %s, want %s", test.method, test.path, route.Handler, test.wantRoute)
+			}
+
+			if !maps.Equal(params, test.wantParams) {
+				t.Errorf("MatchRoute(%s %s) params = %v, want %v", test.method, test.path, params, test.wantParams)
+			}
+		})
+	}
+}
+
+func TestStripStage(t *testing.T) {
+	tests := []struct {
+		name    string
+		rawPath string
+		stage   string
+		want    string
+	}{
+		{"no stage", "/orders", "", "/orders"},
+		{"default stage", "/orders", "$default", "/orders"},
+		{"named stage", "/prod/orders", "prod", "/orders"},
+		{"stage root", "/prod", "prod", "/"},
+		{"stage root with slash", "/prod/", "prod", "/"},
+		{"stage is a prefix of a segment", "/products", "prod", "/products"},
+		{"path without stage", "/orders", "prod", "/orders"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := StripStage(test.rawPath, test.stage); got != test.want {
+				t.Errorf("StripStage(%q, %q) = %q, want %q", test.rawPath, test.stage, got, test.want)
+			}
+		})
+	}
+}